@@ -0,0 +1,31 @@
+package timenlp
+
+import "time"
+
+// Clock 时钟接口，TimeNormalizer通过Clock获取当前时间
+type Clock interface {
+	// Now 当前时间
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+// Now 系统当前时间
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type fixedClock time.Time
+
+// Now 固定时间
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// SystemClock 系统时钟
+var SystemClock Clock = systemClock{}
+
+// FixedClock 返回始终停留在t的时钟，用于按原始时间重新处理历史文本
+func FixedClock(t time.Time) Clock {
+	return fixedClock(t)
+}
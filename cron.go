@@ -0,0 +1,127 @@
+package timenlp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Cron 转换为cron表达式，withSeconds为false时为标准的5个字段“分 时 日 月 星期”，
+// 为true时在最前面增加秒字段。间隔天数、农历日期、次数限制等无法用cron表示的规则返回*CronError
+func (r Recurrence) Cron(withSeconds bool) (string, error) {
+	unsupported := func(reason string) (string, error) {
+		return "", &CronError{RRule: r.RRule(), Reason: reason}
+	}
+	switch {
+	case r.Lunar:
+		return unsupported("lunar date")
+	case r.Count > 0 || !r.Until.IsZero():
+		return unsupported("count or until")
+	case r.ExceptHolidays:
+		return unsupported("holidays")
+	case len(r.ByMonthDay) > 0 && len(r.ByDay) > 0:
+		return unsupported("both month day and weekday")
+	}
+	for _, v := range r.ByMonthDay {
+		if v < 1 {
+			return unsupported("month day from end")
+		}
+	}
+	for _, v := range r.ByDay {
+		if v.N != 0 {
+			return unsupported("nth weekday")
+		}
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	start := r.Start
+	second := cronList(r.orDefault(r.BySecond, start.Second()))
+	minute := cronList(r.orDefault(r.ByMinute, start.Minute()))
+	hour := cronList(r.orDefault(r.ByHour, start.Hour()))
+	day, month, weekday := "*", "*", "*"
+	if len(r.ByMonth) > 0 {
+		month = cronList(r.ByMonth)
+	}
+	if len(r.ByMonthDay) > 0 {
+		day = cronList(r.ByMonthDay)
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]int, 0, len(r.ByDay))
+		for _, v := range r.ByDay {
+			days = append(days, int(v.Weekday))
+		}
+		weekday = cronList(days)
+	}
+	var ok bool
+	switch r.Freq {
+	case SECONDLY:
+		second, ok = cronStep(start.Second(), interval, 0, 59)
+		minute, hour = "*", "*"
+	case MINUTELY:
+		minute, ok = cronStep(start.Minute(), interval, 0, 59)
+		hour = "*"
+	case HOURLY:
+		hour, ok = cronStep(start.Hour(), interval, 0, 23)
+	case DAILY:
+		ok = interval == 1
+	case WEEKLY:
+		ok = interval == 1
+		if len(r.ByDay) == 0 {
+			weekday = strconv.Itoa(int(start.Weekday()))
+		}
+	case MONTHLY:
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			day = strconv.Itoa(start.Day())
+		}
+		if interval > 1 {
+			if len(r.ByMonth) > 0 {
+				return unsupported("interval with month")
+			}
+			month, ok = cronStep(int(start.Month()), interval, 1, 12)
+		} else {
+			ok = true
+		}
+	case YEARLY:
+		ok = interval == 1
+		if len(r.ByMonth) == 0 {
+			month = strconv.Itoa(int(start.Month()))
+		}
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			day = strconv.Itoa(start.Day())
+		}
+	}
+	if !ok {
+		return unsupported("interval")
+	}
+	fields := []string{minute, hour, day, month, weekday}
+	if withSeconds {
+		fields = append([]string{second}, fields...)
+	} else if second != "0" {
+		return unsupported("seconds")
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// cronList 逗号分隔的取值列表
+func cronList(values []int) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, strconv.Itoa(v))
+	}
+	return strings.Join(strs, ",")
+}
+
+// cronStep 从start开始每隔interval的取值，只有interval能整除取值范围时才能用cron表示
+func cronStep(start int, interval int, min int, max int) (string, bool) {
+	if interval == 1 {
+		return "*", true
+	}
+	if (max-min+1)%interval != 0 {
+		return "", false
+	}
+	if first := min + (start-min)%interval; first != min {
+		return strconv.Itoa(first) + "-" + strconv.Itoa(max) + "/" + strconv.Itoa(interval), true
+	}
+	return "*/" + strconv.Itoa(interval), true
+}
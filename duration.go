@@ -0,0 +1,64 @@
+package timenlp
+
+import (
+	"math"
+	"time"
+)
+
+// Duration 时间长度，按表达式中给出的单位分别记录
+// JSON编码为ISO 8601格式，如"P33DT2M4S"
+type Duration struct {
+	Years   int
+	Months  int
+	Weeks   int
+	Days    int
+	Hours   int
+	Minutes int
+	Seconds int
+}
+
+// IsZero 是否为零长度
+func (d Duration) IsZero() bool {
+	return d == Duration{}
+}
+
+// AddTo 按日历计算t之后的时间，年、月、周、日使用AddDate，时、分、秒按实际时长计算
+// 如1月31日加1个月为3月3日（与time.AddDate一致）
+func (d Duration) AddTo(t time.Time) time.Time {
+	t = t.AddDate(d.Years, d.Months, d.Weeks*7+d.Days)
+	seconds := d.clockSeconds()
+	for seconds > maxDurationSeconds {
+		t = t.Add(time.Duration(maxDurationSeconds) * time.Second)
+		seconds -= maxDurationSeconds
+	}
+	for seconds < -maxDurationSeconds {
+		t = t.Add(-time.Duration(maxDurationSeconds) * time.Second)
+		seconds += maxDurationSeconds
+	}
+	return t.Add(time.Duration(seconds) * time.Second)
+}
+
+// Exact 转换为time.Duration，年、月的长度不固定，包含年、月或超出time.Duration范围时返回false
+// 周、日按每天24小时计算
+func (d Duration) Exact() (time.Duration, bool) {
+	if d.Years != 0 || d.Months != 0 {
+		return 0, false
+	}
+	days := int64(d.Weeks)*7 + int64(d.Days)
+	if days > maxDurationSeconds/86400 || days < -maxDurationSeconds/86400 {
+		return 0, false
+	}
+	seconds := d.clockSeconds() + days*86400
+	if seconds > maxDurationSeconds || seconds < -maxDurationSeconds {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// maxDurationSeconds time.Duration能表示的最大秒数
+const maxDurationSeconds = int64(math.MaxInt64 / int64(time.Second))
+
+// clockSeconds 时、分、秒部分的秒数
+func (d Duration) clockSeconds() int64 {
+	return int64(d.Hours)*3600 + int64(d.Minutes)*60 + int64(d.Seconds)
+}
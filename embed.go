@@ -3,6 +3,9 @@ package timenlp
 import (
 	// embed data
 	_ "embed"
+	"encoding/json"
+
+	"github.com/dlclark/regexp2"
 )
 
 //go:embed resource/regex.txt
@@ -13,3 +16,21 @@ var embedHoliSolar []byte
 
 //go:embed resource/holi_lunar.json
 var embedHoliLunar []byte
+
+var (
+	// timePattern 时间表达式识别规则
+	timePattern = regexp2.MustCompile(embedPattern, 0)
+	// holiSolar 阳历节日
+	holiSolar = mustLoadHoliday(embedHoliSolar)
+	// holiLunar 阴历节日
+	holiLunar = mustLoadHoliday(embedHoliLunar)
+)
+
+// mustLoadHoliday 加载节日数据
+func mustLoadHoliday(data []byte) map[string]string {
+	ret := make(map[string]string)
+	if err := json.Unmarshal(data, &ret); err != nil {
+		panic(err)
+	}
+	return ret
+}
@@ -0,0 +1,122 @@
+package timenlp
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInputTooLong 输入文本超过最大长度
+	ErrInputTooLong = errors.New("timenlp: input too long")
+	// ErrMatchTimeout 时间表达式正则匹配超时
+	ErrMatchTimeout = errors.New("timenlp: pattern match timeout")
+)
+
+// InputTooLongError 输入文本超过最大长度，可通过errors.Is(err, ErrInputTooLong)判断
+type InputTooLongError struct {
+	// Length 输入文本长度（字符数）
+	Length int
+	// Max 允许的最大长度（字符数）
+	Max int
+}
+
+// Error implement error interface
+func (e *InputTooLongError) Error() string {
+	return fmt.Sprintf("timenlp: input length %d exceeds limit %d", e.Length, e.Max)
+}
+
+// Unwrap 返回ErrInputTooLong
+func (e *InputTooLongError) Unwrap() error {
+	return ErrInputTooLong
+}
+
+var (
+	// ErrNoMatch 没有识别到时间表达式
+	ErrNoMatch = errors.New("timenlp: no time pattern could be extracted")
+	// ErrOutOfRange 数值或日期超出支持范围
+	ErrOutOfRange = errors.New("timenlp: value out of supported range")
+	// ErrInvalidDate 不存在的日期或时间，如2月30日
+	ErrInvalidDate = errors.New("timenlp: invalid date")
+	// ErrCanceled 解析被取消或超过截止时间
+	ErrCanceled = errors.New("timenlp: parse canceled")
+	// ErrSchemaVersion JSON数据的schema版本不受支持
+	ErrSchemaVersion = errors.New("timenlp: unsupported schema version")
+	// ErrInvalidFormat ISO 8601时间长度或时间区间格式错误
+	ErrInvalidFormat = errors.New("timenlp: invalid ISO 8601 format")
+	// ErrCronUnsupported 重复时间无法用cron表达式表示
+	ErrCronUnsupported = errors.New("timenlp: recurrence not representable as cron")
+)
+
+// RangeError 数值或日期超出支持范围，可通过errors.Is(err, ErrOutOfRange)判断
+type RangeError struct {
+	// Field 超出范围的字段，如"lunar year"
+	Field string
+	// Value 原始值
+	Value string
+	// Min 支持的最小值
+	Min int
+	// Max 支持的最大值
+	Max int
+}
+
+// Error implement error interface
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("timenlp: %s %s out of supported range [%d, %d]", e.Field, e.Value, e.Min, e.Max)
+}
+
+// Unwrap 返回ErrOutOfRange
+func (e *RangeError) Unwrap() error {
+	return ErrOutOfRange
+}
+
+// InvalidDateError 不存在的日期或时间，可通过errors.Is(err, ErrInvalidDate)判断
+type InvalidDateError struct {
+	// Expression 时间表达式
+	Expression string
+	// Point 识别出的年-月-日-时-分-秒，未知字段为-1
+	Point TimePoint
+}
+
+// Error implement error interface
+func (e *InvalidDateError) Error() string {
+	return fmt.Sprintf("timenlp: invalid date %q %v", e.Expression, [6]int(e.Point))
+}
+
+// Unwrap 返回ErrInvalidDate
+func (e *InvalidDateError) Unwrap() error {
+	return ErrInvalidDate
+}
+
+// CanceledError 解析被取消，可通过errors.Is(err, ErrCanceled)或errors.Is(err, context.Canceled)判断
+type CanceledError struct {
+	// Err ctx.Err()
+	Err error
+}
+
+// Error implement error interface
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("timenlp: parse canceled: %v", e.Err)
+}
+
+// Unwrap 返回ErrCanceled和ctx.Err()
+func (e *CanceledError) Unwrap() []error {
+	return []error{ErrCanceled, e.Err}
+}
+
+// CronError 重复时间无法用cron表达式表示，可通过errors.Is(err, ErrCronUnsupported)判断
+type CronError struct {
+	// RRule 重复时间规则
+	RRule string
+	// Reason 无法表示的原因，如"interval"、"lunar date"
+	Reason string
+}
+
+// Error implement error interface
+func (e *CronError) Error() string {
+	return fmt.Sprintf("timenlp: recurrence %q not representable as cron: %s", e.RRule, e.Reason)
+}
+
+// Unwrap 返回ErrCronUnsupported
+func (e *CronError) Unwrap() error {
+	return ErrCronUnsupported
+}
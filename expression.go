@@ -0,0 +1,162 @@
+package timenlp
+
+import (
+	"regexp"
+	"time"
+)
+
+// ExpressionKind 时间表达式类型
+type ExpressionKind string
+
+const (
+	// INSTANT 时间点，如：明天下午三点
+	INSTANT ExpressionKind = "instant"
+	// INTERVAL 时间区间，如：周四下午三点到五点
+	INTERVAL ExpressionKind = "interval"
+	// DURATION 时间长度，如：33天2分钟4秒
+	DURATION ExpressionKind = "duration"
+	// RECURRENCE 重复时间，如：每周一上午九点
+	RECURRENCE ExpressionKind = "recurrence"
+)
+
+// Expression 输入中一个独立的时间表达式
+type Expression struct {
+	// Kind 表达式类型
+	Kind ExpressionKind `json:"kind,omitempty"`
+	// Span 表达式在原始输入中的位置
+	Span
+	// Points 表达式包含的时间点，区间为起止两个时间点，重复时间为第一次发生的时间
+	Points []ResultPoint `json:"points,omitempty"`
+	// Interval 表达式对应的时间区间，区间表达式为开始时间点的开始到结束时间点的结束
+	Interval Interval `json:"interval"`
+	// Recurrence 重复时间规则，其他表达式为nil
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// Schedule 重复时间的计算器，其他表达式为nil
+	Schedule *Schedule `json:"-"`
+}
+
+var (
+	// intervalConnectorPattern 连接区间起止时间的文字
+	intervalConnectorPattern = regexp.MustCompile(`^(到|至|~|～|-|－|—|–)$`)
+	// listConnectorPattern 并列时间之间的文字，如“每天早上8点和晚上8点”
+	listConnectorPattern = regexp.MustCompile(`^(和|及|与|、|,|，)$`)
+)
+
+// expressions 将识别出的时间单元划分为独立的时间表达式
+// 相邻的两个时间点之间只有区间连接词时合并为一个区间，重复时间规则及其中的时间单元合并为一个重复时间
+func (n *TimeNormalizer) expressions(text *trackedString, units []TimeUnit, recurrences []recurrenceMatch, ref time.Time) []Expression {
+	runes := []rune(text.String())
+	owner := make([]int, len(units))
+	for idx := range owner {
+		owner[idx] = -1
+	}
+	groups := make([][]TimeUnit, len(recurrences))
+	bounds := make([][2]int, len(recurrences))
+	for ri, m := range recurrences {
+		start, end := m.start, m.end
+		for ui, unit := range units {
+			if owner[ui] != -1 || unit.pos+unit.length <= m.start {
+				continue
+			}
+			if unit.pos > end && unit.pos >= m.countEnd && !(unit.pos <= len(runes) && listConnectorPattern.MatchString(string(runes[end:unit.pos])) && !unit.hasDate()) {
+				break
+			}
+			owner[ui] = ri
+			groups[ri] = append(groups[ri], unit)
+			if unit.pos < start {
+				start = unit.pos
+			}
+			if unit.pos+unit.length > end {
+				end = unit.pos + unit.length
+			}
+		}
+		if m.countEnd > end {
+			end = m.countEnd
+		}
+		if m.except[1] > m.except[0] {
+			if m.except[0] < start {
+				start = m.except[0]
+			}
+			if m.except[1] > end {
+				end = m.except[1]
+			}
+		}
+		bounds[ri] = [2]int{start, end}
+	}
+	ret := make([]Expression, 0, len(units)+len(recurrences))
+	for idx, ri := 0, 0; idx < len(units) || ri < len(recurrences); {
+		if ri < len(recurrences) && (idx >= len(units) || bounds[ri][0] <= units[idx].pos) {
+			if e, ok := n.recurrenceExpression(text, recurrences[ri], groups[ri], bounds[ri], ref); ok {
+				ret = append(ret, e)
+			}
+			ri++
+			continue
+		}
+		unit := units[idx]
+		idx++
+		if owner[idx-1] != -1 {
+			continue
+		}
+		if unit.isTimeSpan {
+			ret = append(ret, Expression{
+				Kind:     DURATION,
+				Span:     unit.span,
+				Points:   []ResultPoint{unit.ToResultPoint()},
+				Interval: unit.interval,
+			})
+			continue
+		}
+		if idx < len(units) && owner[idx] == -1 {
+			next := units[idx]
+			end := unit.pos + unit.length
+			if !next.isTimeSpan && end <= next.pos && next.pos <= len(runes) && intervalConnectorPattern.MatchString(string(runes[end:next.pos])) {
+				ret = append(ret, Expression{
+					Kind:     INTERVAL,
+					Span:     text.span(unit.pos, next.pos+next.length),
+					Points:   []ResultPoint{unit.ToResultPoint(), next.ToResultPoint()},
+					Interval: Interval{Start: unit.interval.Start, End: next.interval.End},
+				})
+				idx++
+				continue
+			}
+		}
+		ret = append(ret, Expression{
+			Kind:     INSTANT,
+			Span:     unit.span,
+			Points:   []ResultPoint{unit.ToResultPoint()},
+			Interval: unit.interval,
+		})
+	}
+	return ret
+}
+
+// recurrenceExpression 生成重复时间表达式，时间点为第一次发生的时间
+func (n *TimeNormalizer) recurrenceExpression(text *trackedString, m recurrenceMatch, units []TimeUnit, bounds [2]int, ref time.Time) (Expression, bool) {
+	rule := m.recurrence(units, ref)
+	if rule.Start.IsZero() {
+		return Expression{}, false
+	}
+	span := text.span(bounds[0], bounds[1])
+	var point ResultPoint
+	for _, unit := range units {
+		if !unit.isTimeSpan {
+			point = unit.ToResultPoint()
+			break
+		}
+	}
+	if point.Granularity == "" {
+		point.Granularity = rule.granularity()
+	}
+	point.Time = rule.Start
+	point.Span = span
+	point.Duration = nil
+	point.Interval = Interval{Start: rule.Start, End: granularityEnd(rule.Start, point.Granularity)}
+	return Expression{
+		Kind:       RECURRENCE,
+		Span:       span,
+		Points:     []ResultPoint{point},
+		Interval:   point.Interval,
+		Recurrence: &rule,
+		Schedule:   n.Schedule(rule),
+	}, true
+}
@@ -0,0 +1,68 @@
+package timenlp
+
+import "time"
+
+// Granularity 时间精度，即表达式明确到的最小时间单位
+type Granularity string
+
+const (
+	// YEAR 年
+	YEAR Granularity = "year"
+	// MONTH 月
+	MONTH Granularity = "month"
+	// DAY 日
+	DAY Granularity = "day"
+	// HOUR 时
+	HOUR Granularity = "hour"
+	// MINUTE 分
+	MINUTE Granularity = "minute"
+	// SECOND 秒
+	SECOND Granularity = "second"
+)
+
+// timePointGranularities TimePoint各字段对应的精度
+var timePointGranularities = [6]Granularity{YEAR, MONTH, DAY, HOUR, MINUTE, SECOND}
+
+// FieldSource TimePoint字段的来源
+type FieldSource string
+
+const (
+	// FIELD_UNSET 未设置，按最小值补齐
+	FIELD_UNSET FieldSource = ""
+	// FIELD_EXPLICIT 表达式中明确给出，包括“明天”这类相对表达式推算出的字段
+	FIELD_EXPLICIT FieldSource = "explicit"
+	// FIELD_CONTEXT 根据上一个时间表达式推断
+	FIELD_CONTEXT FieldSource = "context"
+	// FIELD_BASE 根据基准时间补齐
+	FIELD_BASE FieldSource = "base"
+)
+
+// FieldSources 年-月-日-时-分-秒六个字段的来源，下标与TimePoint一致
+type FieldSources [6]FieldSource
+
+// Explicit 是否所有已设置的字段都在表达式中明确给出
+func (f FieldSources) Explicit() bool {
+	for _, v := range f {
+		if v == FIELD_CONTEXT || v == FIELD_BASE {
+			return false
+		}
+	}
+	return true
+}
+
+// granularityEnd 按时间精度计算t所在区间的结束时间
+func granularityEnd(t time.Time, g Granularity) time.Time {
+	switch g {
+	case YEAR:
+		return t.AddDate(1, 0, 0)
+	case MONTH:
+		return t.AddDate(0, 1, 0)
+	case DAY:
+		return t.AddDate(0, 0, 1)
+	case HOUR:
+		return t.Add(time.Hour)
+	case MINUTE:
+		return t.Add(time.Minute)
+	}
+	return t.Add(time.Second)
+}
@@ -0,0 +1,85 @@
+package timenlp
+
+import "time"
+
+// HolidayCalendar 节假日日历，用于重复时间跳过节假日
+type HolidayCalendar interface {
+	// IsHoliday date所在的日期是否为节假日
+	IsHoliday(date time.Time) bool
+}
+
+type statutoryHolidays struct{}
+
+// IsHoliday 按《全国年节及纪念日放假办法》判断是否为全体公民放假的法定节假日
+// 只包含法定的放假日期，不包含每年国务院公布的调休安排
+func (statutoryHolidays) IsHoliday(date time.Time) bool {
+	year, month, day := date.Date()
+	switch {
+	case month == time.January && day == 1:
+		// 元旦
+		return true
+	case month == time.May && (day == 1 || day == 2 && year >= 2025):
+		// 劳动节，2025年起放假2天
+		return true
+	case month == time.October && day <= 3:
+		// 国庆节
+		return true
+	}
+	if year >= solarTermMinYear && year <= solarTermMaxYear {
+		// 清明节
+		if qm := china24St(year, "清明"); int(month) == qm[0] && day == qm[1] {
+			return true
+		}
+	}
+	lunarDate := func(month, day int) (time.Time, bool) {
+		lunar := Lunar{Year: year, Month: month, Day: day}
+		if !lunarSolarConverter.IsSupported(lunar) {
+			return time.Time{}, false
+		}
+		solar := lunarSolarConverter.LunarToSolar(lunar)
+		return time.Date(solar.Year, time.Month(solar.Month), solar.Day, 0, 0, 0, 0, time.UTC), true
+	}
+	current := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// 端午节、中秋节
+	for _, v := range [][2]int{{5, 5}, {8, 15}} {
+		if solar, ok := lunarDate(v[0], v[1]); ok && solar.Equal(current) {
+			return true
+		}
+	}
+	// 春节，2014年以前为除夕、初一、初二，2014年至2024年为初一至初三，2025年起为除夕、初一至初三
+	newYear, ok := lunarDate(1, 1)
+	if !ok {
+		return false
+	}
+	first, last := -1, 2
+	if year < 2014 {
+		last = 1
+	} else if year < 2025 {
+		first = 0
+	}
+	offset := int(current.Sub(newYear) / (24 * time.Hour))
+	return offset >= first && offset <= last
+}
+
+// holidayDates 固定日期的节假日
+type holidayDates map[[3]int]struct{}
+
+// IsHoliday 是否为指定的日期
+func (h holidayDates) IsHoliday(date time.Time) bool {
+	year, month, day := date.Date()
+	_, found := h[[3]int{year, int(month), day}]
+	return found
+}
+
+// StatutoryHolidays 法定节假日日历，默认的节假日日历
+var StatutoryHolidays HolidayCalendar = statutoryHolidays{}
+
+// HolidayDates 返回由指定日期组成的节假日日历，可用于导入每年公布的放假安排，日期按各自的时区取年月日
+func HolidayDates(dates ...time.Time) HolidayCalendar {
+	ret := make(holidayDates, len(dates))
+	for _, date := range dates {
+		year, month, day := date.Date()
+		ret[[3]int{year, int(month), day}] = struct{}{}
+	}
+	return ret
+}
@@ -0,0 +1,117 @@
+package timenlp
+
+import "time"
+
+// Interval 时间区间，默认为左闭右开区间[Start, End)
+type Interval struct {
+	// Start 开始时间
+	Start time.Time `json:"start"`
+	// End 结束时间
+	End time.Time `json:"end"`
+	// EndInclusive End是否包含在区间内
+	EndInclusive bool `json:"end_inclusive,omitempty"`
+}
+
+// IsZero 是否为空区间
+func (i Interval) IsZero() bool {
+	return i.Start.IsZero() && i.End.IsZero()
+}
+
+// Contains 时间是否在区间内
+func (i Interval) Contains(t time.Time) bool {
+	if t.Before(i.Start) {
+		return false
+	}
+	if i.EndInclusive {
+		return !t.After(i.End)
+	}
+	return t.Before(i.End)
+}
+
+// Inclusive 转换为闭区间[Start, End]，End为区间内的最后一个时刻
+func (i Interval) Inclusive() Interval {
+	if i.EndInclusive || i.IsZero() {
+		return i
+	}
+	return Interval{
+		Start:        i.Start,
+		End:          i.End.Add(-time.Nanosecond),
+		EndInclusive: true,
+	}
+}
+
+// Exclusive 转换为左闭右开区间[Start, End)
+func (i Interval) Exclusive() Interval {
+	if !i.EndInclusive || i.IsZero() {
+		return i
+	}
+	return Interval{
+		Start: i.Start,
+		End:   i.End.Add(time.Nanosecond),
+	}
+}
+
+// DayPeriodWindow 时段对应的时间窗口，Start、End为相对当天0点的偏移，End可以超过24小时表示跨天
+type DayPeriodWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// defaultDayPeriodWindows 时段默认的时间窗口
+var defaultDayPeriodWindows = map[RangeTimeEnum]DayPeriodWindow{
+	DAY_BREAK:     {Start: 0, End: 6 * time.Hour},
+	EARLY_MORNING: {Start: 6 * time.Hour, End: 9 * time.Hour},
+	MORNING:       {Start: 8 * time.Hour, End: 12 * time.Hour},
+	NOON:          {Start: 11 * time.Hour, End: 13 * time.Hour},
+	AFTERNOON:     {Start: 12 * time.Hour, End: 18 * time.Hour},
+	NIGHT:         {Start: 18 * time.Hour, End: 24 * time.Hour},
+	LATE_NIGHT:    {Start: 18 * time.Hour, End: 24 * time.Hour},
+	MID_NIGHT:     {Start: 23 * time.Hour, End: 27 * time.Hour},
+}
+
+// dayPeriodWindow 时段对应的时间窗口
+func (n *TimeNormalizer) dayPeriodWindow(period RangeTimeEnum) (DayPeriodWindow, bool) {
+	if w, found := n.dayPeriodWindows[period]; found {
+		return w, true
+	}
+	w, found := defaultDayPeriodWindows[period]
+	return w, found
+}
+
+// monthPartDays 月内时段的第一天和最后一天，“上旬”、“中旬”、“下旬”分别为1-10日、11-20日、21日至月末
+func (n *TimeNormalizer) monthPartDays(part string, year int, month time.Month) (first int, last int) {
+	days := daysIn(year, month)
+	switch part {
+	case "上旬":
+		return 1, 10
+	case "中旬", "月中":
+		return 11, 20
+	case "下旬":
+		return 21, days
+	case "月初":
+		return 1, n.monthHeadDays
+	case "月底":
+		return days - n.monthTailDays + 1, days
+	}
+	return 1, days
+}
+
+// fiscalYear 时间所在的财年，财年以开始的年份命名
+func (n *TimeNormalizer) fiscalYear(t time.Time) int {
+	if t.Month() < n.fiscalYearStartMonth {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// fiscalYearStart 财年的第一天
+func (n *TimeNormalizer) fiscalYearStart(year int, loc *time.Location) time.Time {
+	return time.Date(year, n.fiscalYearStartMonth, 1, 0, 0, 0, 0, loc)
+}
+
+// quarterStart 时间所在季度的第一天，季度按财年划分
+func (n *TimeNormalizer) quarterStart(t time.Time) time.Time {
+	start := n.fiscalYearStart(n.fiscalYear(t), t.Location())
+	months := (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+	return start.AddDate(0, months/3*3, 0)
+}
@@ -0,0 +1,234 @@
+package timenlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchemaVersion Result的JSON格式版本
+// 版本1：
+//   - 时间点为RFC 3339格式，如"2025-07-21T10:02:04+08:00"
+//   - 时间区间为ISO 8601格式"start/end"，按左闭右开区间编码
+//   - 时间长度为ISO 8601格式，如"P33DT2M4S"，包含周时如"P2W3D"
+//   - 重复时间为{"rrule": RFC 5545的RRULE值, "start": 第一次发生的时间, "except_holidays": 是否跳过节假日}
+//   - 字段来源按年-月-日-时-分-秒顺序输出，未设置的字段为""
+//   - 位置、精度、表达式类型见各结构体的json tag
+const JSONSchemaVersion = "1"
+
+// MarshalJSON implement json.Marshaler interface
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		SchemaVersion string `json:"schema_version"`
+		result
+	}{
+		SchemaVersion: JSONSchemaVersion,
+		result:        result(r),
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler interface
+func (r *Result) UnmarshalJSON(data []byte) error {
+	type result Result
+	var ret struct {
+		SchemaVersion string `json:"schema_version"`
+		result
+	}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return err
+	}
+	if ret.SchemaVersion != "" && ret.SchemaVersion != JSONSchemaVersion {
+		return fmt.Errorf("%w: %q", ErrSchemaVersion, ret.SchemaVersion)
+	}
+	*r = Result(ret.result)
+	return nil
+}
+
+// String ISO 8601格式的时间区间，按左闭右开区间输出
+func (i Interval) String() string {
+	if i.IsZero() {
+		return ""
+	}
+	i = i.Exclusive()
+	return i.Start.Format(time.RFC3339Nano) + "/" + i.End.Format(time.RFC3339Nano)
+}
+
+// MarshalJSON implement json.Marshaler interface
+func (i Interval) MarshalJSON() ([]byte, error) {
+	if i.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implement json.Unmarshaler interface
+func (i *Interval) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*i = Interval{}
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	ret, err := ParseISOInterval(str)
+	if err != nil {
+		return err
+	}
+	*i = ret
+	return nil
+}
+
+// ParseISOInterval 解析ISO 8601格式"start/end"的时间区间，start、end为RFC 3339格式
+func ParseISOInterval(str string) (Interval, error) {
+	parts := strings.Split(str, "/")
+	if len(parts) != 2 {
+		return Interval{}, fmt.Errorf("%w: interval %q", ErrInvalidFormat, str)
+	}
+	start, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Interval{}, fmt.Errorf("%w: interval %q", ErrInvalidFormat, str)
+	}
+	end, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return Interval{}, fmt.Errorf("%w: interval %q", ErrInvalidFormat, str)
+	}
+	return Interval{Start: start, End: end}, nil
+}
+
+// String ISO 8601格式的时间长度，如"P1Y2M3W4DT5H6M7S"，零长度为"PT0S"，负数长度为"-P3D"
+// 各字段正负不一致时ISO 8601无法表示，各字段分别带符号，如"P1M-3D"，仅用于显示
+func (d Duration) String() string {
+	if d.IsZero() {
+		return "PT0S"
+	}
+	var (
+		sb   strings.Builder
+		sign = 1
+	)
+	if d.negative() {
+		sb.WriteString("-")
+		sign = -1
+	}
+	sb.WriteString("P")
+	write := func(v int, unit string) {
+		if v != 0 {
+			sb.WriteString(strconv.Itoa(v * sign))
+			sb.WriteString(unit)
+		}
+	}
+	write(d.Years, "Y")
+	write(d.Months, "M")
+	write(d.Weeks, "W")
+	write(d.Days, "D")
+	if d.Hours != 0 || d.Minutes != 0 || d.Seconds != 0 {
+		sb.WriteString("T")
+		write(d.Hours, "H")
+		write(d.Minutes, "M")
+		write(d.Seconds, "S")
+	}
+	return sb.String()
+}
+
+// negative 是否所有非零字段均为负数
+func (d Duration) negative() bool {
+	return d.Years <= 0 && d.Months <= 0 && d.Weeks <= 0 && d.Days <= 0 && d.Hours <= 0 && d.Minutes <= 0 && d.Seconds <= 0
+}
+
+// mixedSigns 是否同时包含正数和负数字段
+func (d Duration) mixedSigns() bool {
+	return !d.negative() && (d.Years < 0 || d.Months < 0 || d.Weeks < 0 || d.Days < 0 || d.Hours < 0 || d.Minutes < 0 || d.Seconds < 0)
+}
+
+// MarshalJSON implement json.Marshaler interface，各字段正负不一致时返回ErrInvalidFormat
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d.mixedSigns() {
+		return nil, fmt.Errorf("%w: duration %s mixes positive and negative fields", ErrInvalidFormat, d)
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implement json.Unmarshaler interface
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	ret, err := ParseISODuration(str)
+	if err != nil {
+		return err
+	}
+	*d = ret
+	return nil
+}
+
+// isoDurationPattern ISO 8601时间长度
+var isoDurationPattern = regexp.MustCompile(`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseISODuration 解析ISO 8601格式的时间长度，如"P33DT2M4S"
+func ParseISODuration(str string) (Duration, error) {
+	match := isoDurationPattern.FindStringSubmatch(str)
+	if match == nil || str == "P" || str == "-P" || strings.HasSuffix(str, "T") {
+		return Duration{}, fmt.Errorf("%w: duration %q", ErrInvalidFormat, str)
+	}
+	sign := 1
+	if match[1] != "" {
+		sign = -1
+	}
+	var values [7]int
+	for idx, v := range match[2:] {
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Duration{}, fmt.Errorf("%w: duration %q", ErrInvalidFormat, str)
+		}
+		values[idx] = n * sign
+	}
+	return Duration{
+		Years:   values[0],
+		Months:  values[1],
+		Weeks:   values[2],
+		Days:    values[3],
+		Hours:   values[4],
+		Minutes: values[5],
+		Seconds: values[6],
+	}, nil
+}
+
+// recurrenceJSON Recurrence的JSON格式
+type recurrenceJSON struct {
+	RRule          string    `json:"rrule"`
+	Start          time.Time `json:"start"`
+	ExceptHolidays bool      `json:"except_holidays,omitempty"`
+}
+
+// MarshalJSON implement json.Marshaler interface
+func (r Recurrence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(recurrenceJSON{
+		RRule:          r.RRule(),
+		Start:          r.Start,
+		ExceptHolidays: r.ExceptHolidays,
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler interface
+func (r *Recurrence) UnmarshalJSON(data []byte) error {
+	var v recurrenceJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	ret, err := ParseRRule(v.RRule)
+	if err != nil {
+		return err
+	}
+	ret.Start = v.Start
+	ret.ExceptHolidays = v.ExceptHolidays
+	*r = ret
+	return nil
+}
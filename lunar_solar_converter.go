@@ -58,6 +58,9 @@ type LunarSolarConverter struct {
 	Solar []int
 }
 
+// lunarSolarConverter 共享的阴历阳历转换，数据只读
+var lunarSolarConverter = NewLunarSolarConverter()
+
 // NewLunarSolarConverter 新建阴历阳历转换结构体
 func NewLunarSolarConverter() *LunarSolarConverter {
 	return &LunarSolarConverter{
@@ -138,8 +141,48 @@ func NewLunarSolarConverter() *LunarSolarConverter {
 	}
 }
 
-// LunarToSolar 转换阴历到阳历
+// MinYear 支持转换的最小农历年份
+func (l *LunarSolarConverter) MinYear() int {
+	return l.LunarMonthDays[0] + 1
+}
+
+// MaxYear 支持转换的最大农历年份
+func (l *LunarSolarConverter) MaxYear() int {
+	return l.LunarMonthDays[0] + len(l.LunarMonthDays) - 1
+}
+
+// IsSupported 是否支持该农历日期的转换
+func (l *LunarSolarConverter) IsSupported(lunar Lunar) bool {
+	return lunar.Year >= l.MinYear() && lunar.Year <= l.MaxYear() &&
+		lunar.Month >= 1 && lunar.Month <= 12 &&
+		lunar.Day >= 1 && lunar.Day <= 30
+}
+
+// MonthDays 阴历某年某月的天数，IsLeap为闰月，忽略Day，不支持的年、月返回0
+func (l *LunarSolarConverter) MonthDays(lunar Lunar) int {
+	if lunar.Year < l.MinYear() || lunar.Year > l.MaxYear() || lunar.Month < 1 || lunar.Month > 12 {
+		return 0
+	}
+	days := l.LunarMonthDays[lunar.Year-l.LunarMonthDays[0]]
+	leap := l.GetBigInt(days, 4, 13)
+	// 该月之前的月数，闰月排在同名月份之后
+	idx := lunar.Month - 1
+	if lunar.IsLeap {
+		idx = leap
+	} else if leap != 0 && lunar.Month > leap {
+		idx = lunar.Month
+	}
+	if l.GetBigInt(days, 1, 12-idx) == 1 {
+		return 30
+	}
+	return 29
+}
+
+// LunarToSolar 转换阴历到阳历，不支持的日期（见IsSupported）返回零值
 func (l *LunarSolarConverter) LunarToSolar(lunar Lunar) Solar {
+	if !l.IsSupported(lunar) {
+		return Solar{}
+	}
 	days := l.LunarMonthDays[lunar.Year-l.LunarMonthDays[0]]
 	leap := l.GetBigInt(days, 4, 13)
 	var offset int
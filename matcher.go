@@ -0,0 +1,131 @@
+package timenlp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dlclark/regexp2"
+)
+
+// patternSet 设置了同一超时时间的正则副本，在所有TimeNormalizer之间共享
+type patternSet struct {
+	timeout time.Duration
+	// copies 包级正则到副本的映射
+	copies sync.Map
+}
+
+// patternSets 按超时时间缓存的正则副本，超时时间经bucketTimeout取整，副本数量不超过maxTimeoutBucket+1份
+var patternSets sync.Map
+
+// maxTimeoutBucket 超时时间最长为1毫秒的2^maxTimeoutBucket倍（约17分钟）
+const maxTimeoutBucket = 20
+
+// getPatternSet 超时时间为timeout的正则副本，timeout须为bucketTimeout的返回值
+func getPatternSet(timeout time.Duration) *patternSet {
+	if set, found := patternSets.Load(timeout); found {
+		return set.(*patternSet)
+	}
+	set, _ := patternSets.LoadOrStore(timeout, &patternSet{timeout: timeout})
+	return set.(*patternSet)
+}
+
+// re 包级正则pattern对应的副本，第一次使用时编译，本包的正则均不使用RegexOptions
+func (s *patternSet) re(pattern *regexp2.Regexp) *regexp2.Regexp {
+	if re, found := s.copies.Load(pattern); found {
+		return re.(*regexp2.Regexp)
+	}
+	re := regexp2.MustCompile(pattern.String(), 0)
+	re.MatchTimeout = s.timeout
+	actual, _ := s.copies.LoadOrStore(pattern, re)
+	return actual.(*regexp2.Regexp)
+}
+
+// matcher 单次Parse调用中所有regexp2正则的匹配入口，保证每次匹配都受超时时间限制
+// 为nil时直接使用包级正则，不限制匹配时间
+type matcher struct {
+	set *patternSet
+	// err 第一次匹配超时或出错的错误
+	err error
+}
+
+// newMatcher 单次匹配的超时时间为timeout，ctx设置了更早的截止时间时以截止时间为准，timeout<=0时不限制
+func newMatcher(ctx context.Context, timeout time.Duration) *matcher {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); timeout <= 0 || remaining < timeout {
+			// 已过截止时间时仍以最短的超时时间匹配，由ParseContext检查ctx
+			timeout = remaining
+			if timeout < time.Millisecond {
+				timeout = time.Millisecond
+			}
+		}
+	}
+	if timeout <= 0 {
+		return nil
+	}
+	return &matcher{set: getPatternSet(bucketTimeout(timeout))}
+}
+
+// bucketTimeout 实际使用的超时时间，向下取整为1毫秒的2的幂次倍，不超过2^maxTimeoutBucket毫秒，以限制缓存的副本数量
+func bucketTimeout(timeout time.Duration) time.Duration {
+	ret := time.Millisecond
+	for i := 0; i < maxTimeoutBucket && ret*2 <= timeout; i++ {
+		ret *= 2
+	}
+	return ret
+}
+
+// re pattern在本次调用中使用的正则
+func (m *matcher) re(pattern *regexp2.Regexp) *regexp2.Regexp {
+	if m == nil {
+		return pattern
+	}
+	return m.set.re(pattern)
+}
+
+// fail 记录第一次匹配错误，regexp2的超时错误转为ErrMatchTimeout
+func (m *matcher) fail(err error) {
+	if m == nil || err == nil || m.err != nil {
+		return
+	}
+	if strings.HasPrefix(err.Error(), "match timeout") {
+		m.err = fmt.Errorf("%w after %v", ErrMatchTimeout, m.set.timeout)
+	} else {
+		m.err = fmt.Errorf("timenlp: pattern match: %w", err)
+	}
+}
+
+// find 查找text中pattern的第一个匹配，超时时返回nil
+func (m *matcher) find(pattern *regexp2.Regexp, text string) *regexp2.Match {
+	if m != nil && m.err != nil {
+		return nil
+	}
+	match, err := m.re(pattern).FindStringMatch(text)
+	m.fail(err)
+	return match
+}
+
+// findNext 查找pattern在match之后的下一个匹配，超时时返回nil
+func (m *matcher) findNext(pattern *regexp2.Regexp, match *regexp2.Match) *regexp2.Match {
+	if m != nil && m.err != nil {
+		return nil
+	}
+	next, err := m.re(pattern).FindNextMatch(match)
+	m.fail(err)
+	return next
+}
+
+// match text中是否存在pattern的匹配，超时时返回false
+func (m *matcher) match(pattern *regexp2.Regexp, text string) bool {
+	return m.find(pattern, text) != nil
+}
+
+// matchErr 匹配超时或出错时的错误，没有出错时为nil
+func (m *matcher) matchErr() error {
+	if m == nil {
+		return nil
+	}
+	return m.err
+}
@@ -0,0 +1,138 @@
+package timenlp
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// trackedString 预处理过程中被改写的字符串，记录每个字符在原始输入中对应的位置，
+// 用于把在改写后字符串上的匹配结果映射回原始输入
+type trackedString struct {
+	// origin 原始输入
+	origin string
+	// text 改写后的字符串
+	text string
+	// spans text中每个字符对应origin中的字符区间[start, end)
+	spans [][2]int
+}
+
+// newTrackedString 基于原始输入新建trackedString
+func newTrackedString(origin string) *trackedString {
+	spans := make([][2]int, 0, len(origin))
+	var idx int
+	for range origin {
+		spans = append(spans, [2]int{idx, idx + 1})
+		idx++
+	}
+	return &trackedString{
+		origin: origin,
+		text:   origin,
+		spans:  spans,
+	}
+}
+
+// String 改写后的字符串
+func (s *trackedString) String() string {
+	return s.text
+}
+
+// replace 将text中字节区间[start, end)替换为repl，新字符对应被替换字符在原始输入中的区间，
+// 插入（start==end）的字符对应其前一个字符的位置
+func (s *trackedString) replace(start int, end int, repl string) {
+	if start == end && repl == "" {
+		return
+	}
+	runeStart := utf8.RuneCountInString(s.text[:start])
+	runeEnd := runeStart + utf8.RuneCountInString(s.text[start:end])
+	var span [2]int
+	if runeStart < runeEnd {
+		span = [2]int{s.spans[runeStart][0], s.spans[runeEnd-1][1]}
+	} else if runeStart > 0 {
+		span = [2]int{s.spans[runeStart-1][1], s.spans[runeStart-1][1]}
+	} else if len(s.spans) > 0 {
+		span = [2]int{s.spans[0][0], s.spans[0][0]}
+	}
+	replSpans := make([][2]int, utf8.RuneCountInString(repl))
+	for idx := range replSpans {
+		replSpans[idx] = span
+	}
+	spans := make([][2]int, 0, len(s.spans)-(runeEnd-runeStart)+len(replSpans))
+	spans = append(spans, s.spans[:runeStart]...)
+	spans = append(spans, replSpans...)
+	spans = append(spans, s.spans[runeEnd:]...)
+	s.spans = spans
+	s.text = s.text[:start] + repl + s.text[end:]
+}
+
+// replaceFirst 同strings.Replace(text, old, repl, 1)
+func (s *trackedString) replaceFirst(old string, repl string) {
+	if idx := strings.Index(s.text, old); idx >= 0 && old != "" {
+		s.replace(idx, idx+len(old), repl)
+	}
+}
+
+// replaceAll 同strings.ReplaceAll(text, old, repl)
+func (s *trackedString) replaceAll(old string, repl string) {
+	if old == "" {
+		return
+	}
+	var offset int
+	for {
+		idx := strings.Index(s.text[offset:], old)
+		if idx < 0 {
+			return
+		}
+		idx += offset
+		s.replace(idx, idx+len(old), repl)
+		offset = idx + len(repl)
+	}
+}
+
+// replaceAllFunc 同regexp.ReplaceAllStringFunc(text, fn)
+func (s *trackedString) replaceAllFunc(pattern *regexp.Regexp, fn func(string) string) {
+	locs := pattern.FindAllStringIndex(s.text, -1)
+	for idx := len(locs) - 1; idx >= 0; idx-- {
+		loc := locs[idx]
+		s.replace(loc[0], loc[1], fn(s.text[loc[0]:loc[1]]))
+	}
+}
+
+// span 将改写后字符串中的字符区间[start, end)映射为原始输入中的Span
+func (s *trackedString) span(start int, end int) Span {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(s.spans) {
+		end = len(s.spans)
+	}
+	if start >= end {
+		return Span{}
+	}
+	origStart, origEnd := s.spans[start][0], s.spans[end-1][1]
+	if origEnd < origStart {
+		origEnd = origStart
+	}
+	bytePos, byteEnd := -1, len(s.origin)
+	var idx int
+	for offset := range s.origin {
+		if idx == origStart {
+			bytePos = offset
+		}
+		if idx == origEnd {
+			byteEnd = offset
+			break
+		}
+		idx++
+	}
+	if bytePos < 0 {
+		bytePos = len(s.origin)
+	}
+	return Span{
+		Pos:        origStart,
+		Length:     origEnd - origStart,
+		BytePos:    bytePos,
+		ByteLength: byteEnd - bytePos,
+		Text:       s.origin[bytePos:byteEnd],
+	}
+}
@@ -0,0 +1,168 @@
+package timenlp
+
+import "time"
+
+// FillPolicy 未明确给出的低位时间字段的补全策略
+type FillPolicy int
+
+const (
+	// FillStart 比最小已知字段更精细的字段取最小值，如“明天”为明天0点
+	FillStart FillPolicy = iota
+	// FillBase 比最小已知字段更精细的字段取基准时间的值，如“明天”为明天的当前时刻
+	FillBase
+)
+
+// WeekOfMonthConvention 月内第几周的计算规则
+type WeekOfMonthConvention int
+
+const (
+	// WeekOfMonthDays 按日期每7天为一周，第1周为1-7日，第2周为8-14日，最后一周不足7天
+	WeekOfMonthDays WeekOfMonthConvention = iota
+	// WeekOfMonthCalendar 按日历的行计算，第1周为1日所在的周，每周的第一天由WithWeekStart设置，首尾两周只包含本月的日期
+	WeekOfMonthCalendar
+	// WeekOfMonthISO 按ISO 8601的规则计算，周一为每周的第一天，第1周为本月第一个周四所在的周
+	WeekOfMonthISO
+)
+
+// DefaultTwoDigitYearPivot 两位数年份的默认分界值，小于该值视为20xx年，否则视为19xx年
+const DefaultTwoDigitYearPivot = 30
+
+// DefaultMonthEdgeDays “月初”、“月底”默认包含的天数
+const DefaultMonthEdgeDays = 5
+
+// DefaultMatchTimeout 时间表达式正则单次匹配的默认超时时间
+const DefaultMatchTimeout = time.Second
+
+// Option TimeNormalizer配置项
+type Option func(*TimeNormalizer)
+
+// WithPreferFuture 是否倾向使用未来时间
+func WithPreferFuture(preferFuture bool) Option {
+	return func(n *TimeNormalizer) {
+		n.isPreferFuture = preferFuture
+	}
+}
+
+// WithClock 设置获取当前时间的时钟
+func WithClock(clock Clock) Option {
+	return func(n *TimeNormalizer) {
+		if clock == nil {
+			clock = SystemClock
+		}
+		n.clock = clock
+	}
+}
+
+// WithLocation 设置时区，基准时间会先转换到该时区再计算，默认使用基准时间自身的时区
+func WithLocation(loc *time.Location) Option {
+	return func(n *TimeNormalizer) {
+		n.location = loc
+	}
+}
+
+// WithWeekStart 设置每周的第一天，默认为周日
+func WithWeekStart(day time.Weekday) Option {
+	return func(n *TimeNormalizer) {
+		if day >= time.Sunday && day <= time.Saturday {
+			n.weekStart = day
+		}
+	}
+}
+
+// WithWeekOfMonth 设置“11月第3周”等月内第几周的计算规则，默认为WeekOfMonthDays
+func WithWeekOfMonth(convention WeekOfMonthConvention) Option {
+	return func(n *TimeNormalizer) {
+		n.weekOfMonthConvention = convention
+	}
+}
+
+// WithTwoDigitYearPivot 设置两位数年份的分界值，如pivot为30时，“29年”为2029年，“30年”为1930年
+func WithTwoDigitYearPivot(pivot int) Option {
+	return func(n *TimeNormalizer) {
+		if pivot >= 0 && pivot <= 100 {
+			n.twoDigitYearPivot = pivot
+		}
+	}
+}
+
+// WithDayPeriodDefaults 设置“早上”、“下午”等时段在没有明确时间时的默认小时
+func WithDayPeriodDefaults(defaults map[RangeTimeEnum]int) Option {
+	return func(n *TimeNormalizer) {
+		periods := make(map[RangeTimeEnum]int, len(n.dayPeriods)+len(defaults))
+		for k, v := range n.dayPeriods {
+			periods[k] = v
+		}
+		for k, v := range defaults {
+			if v >= 0 && v < 24 {
+				periods[k] = v
+			}
+		}
+		n.dayPeriods = periods
+	}
+}
+
+// WithDayPeriodWindows 设置“早上”、“下午”等时段在区间视图中对应的时间窗口，如下午默认为12:00-18:00
+func WithDayPeriodWindows(windows map[RangeTimeEnum]DayPeriodWindow) Option {
+	return func(n *TimeNormalizer) {
+		periods := make(map[RangeTimeEnum]DayPeriodWindow, len(n.dayPeriodWindows)+len(windows))
+		for k, v := range n.dayPeriodWindows {
+			periods[k] = v
+		}
+		for k, v := range windows {
+			if v.Start >= 0 && v.End > v.Start {
+				periods[k] = v
+			}
+		}
+		n.dayPeriodWindows = periods
+	}
+}
+
+// WithMonthEdgeDays 设置“月初”、“月底”包含的天数，取值为1-28，如tail为3时“月底”为每月最后3天
+func WithMonthEdgeDays(head int, tail int) Option {
+	return func(n *TimeNormalizer) {
+		if head >= 1 && head <= 28 {
+			n.monthHeadDays = head
+		}
+		if tail >= 1 && tail <= 28 {
+			n.monthTailDays = tail
+		}
+	}
+}
+
+// WithFiscalYearStart 设置财年的第一个月，季度和“财年”均按财年计算，财年以开始的年份命名，默认为1月
+func WithFiscalYearStart(month time.Month) Option {
+	return func(n *TimeNormalizer) {
+		if month >= time.January && month <= time.December {
+			n.fiscalYearStartMonth = month
+		}
+	}
+}
+
+// WithHolidayCalendar 设置重复时间“节假日除外”及“第1个工作日”等使用的节假日日历，默认为StatutoryHolidays
+func WithHolidayCalendar(calendar HolidayCalendar) Option {
+	return func(n *TimeNormalizer) {
+		n.holidays = calendar
+	}
+}
+
+// WithFillPolicy 设置未明确给出的低位时间字段的补全策略
+func WithFillPolicy(policy FillPolicy) Option {
+	return func(n *TimeNormalizer) {
+		n.fillPolicy = policy
+	}
+}
+
+// WithMatchTimeout 设置正则单次匹配的超时时间，包括预处理和时间表达式识别中的所有匹配，d<=0时不限制，
+// ParseContext的ctx设置了更早的截止时间时以截止时间为准；实际使用的超时时间向下取整为1毫秒的2的幂次倍，最短1毫秒，最长约17分钟
+func WithMatchTimeout(d time.Duration) Option {
+	return func(n *TimeNormalizer) {
+		n.matchTimeout = d
+	}
+}
+
+// WithMaxInputLength 设置输入文本的最大字符数，max<=0时不限制
+func WithMaxInputLength(max int) Option {
+	return func(n *TimeNormalizer) {
+		n.maxInputLength = max
+	}
+}
@@ -1,13 +1,19 @@
 package timenlp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
 var (
-	timeBase = time.Now()
-	loc      = timeBase.Location()
+	loc      = time.FixedZone("CST", 8*3600)
+	timeBase = time.Date(2025, 6, 18, 10, 0, 0, 0, loc)
 )
 
 // TestTimepoint 测试时间点
@@ -509,7 +515,7 @@ func TestTomorrowMorning(t *testing.T) {
 // TestLongText1 测试长文字
 func TestLongText1(t *testing.T) {
 	normalizer := NewTimeNormalizer(false)
-	target := `7月 10日晚上7 点左右，六安市公安局裕安分局平桥派出所接到辖区居民戴某报警称，到同学家玩耍的女儿迟迟未归，手机也打不通了。很快，派出所又接到与戴某同住一小区的王女士报警：下午5点左右，12岁的儿子和同学在家中吃过晚饭后，带着3 岁的弟弟一起出了门，之后便没了消息，手机也关机了。短时间内，接到两起孩子失联的报警，值班民警张晖和队友立即前往小区。`
+	target := longText1
 	t.Log(target)
 	expectType := SPAN
 	expectPoints := []time.Time{
@@ -541,7 +547,7 @@ func TestLongText1(t *testing.T) {
 // TestLongText2 测试长文字
 func TestLongText2(t *testing.T) {
 	normalizer := NewTimeNormalizer(false)
-	target := `《辽宁日报》今日报道，7月18日辽宁召开省委常委扩大会，会议从下午两点半开到六点半，主要议题为：落实中央巡视整改要求。`
+	target := longText2
 	t.Log(target)
 	expectType := SPAN
 	expectPoints := []time.Time{
@@ -657,3 +663,1218 @@ func TestSeasons(t *testing.T) {
 		}
 	}
 }
+
+// TestTimeSpanNotLeaking 测试时间长度状态不会影响后续解析
+func TestTimeSpanNotLeaking(t *testing.T) {
+	normalizer := NewTimeNormalizer(false)
+	if _, err := normalizer.Parse("我需要大概33天2分钟四秒", timeBase); err != nil {
+		t.Fatal(err)
+	}
+	target := "2013年二月二十八日下午四点三十分二十九秒"
+	t.Log(target)
+	expectType := TIMESTAMP
+	ret, err := normalizer.Parse(target, timeBase)
+	if err != nil {
+		t.Error(err)
+	} else if ret.Type != expectType {
+		t.Errorf("expect: %s, got: %s", expectType, ret.Type)
+	}
+}
+
+// TestConcurrentParse 测试多个goroutine共享TimeNormalizer
+func TestConcurrentParse(t *testing.T) {
+	targets := []string{
+		"晚上8点到上午10点之间",
+		"我需要大概33天2分钟四秒",
+		"今年儿童节晚上九点一刻",
+		"2个小时以前",
+		"Hi，all.下周一下午三点开会",
+		"周四下午三点到五点开会",
+		"《辽宁日报》今日报道，7月18日辽宁召开省委常委扩大会，会议从下午两点半开到六点半，主要议题为：落实中央巡视整改要求。",
+	}
+	expects := make([]*Result, len(targets))
+	for idx, target := range targets {
+		ret, err := NewTimeNormalizer(true).Parse(target, timeBase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expects[idx] = ret
+	}
+	normalizer := NewTimeNormalizer(true)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for j := range targets {
+				idx := (j + offset) % len(targets)
+				ret, err := normalizer.Parse(targets[idx], timeBase)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !reflect.DeepEqual(ret, expects[idx]) {
+					t.Errorf("expect: %+v, got: %+v", expects[idx], ret)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestFixedClock 测试使用固定时钟解析历史文本
+func TestFixedClock(t *testing.T) {
+	base := time.Date(2019, 3, 6, 9, 30, 0, 0, loc)
+	normalizer := NewTimeNormalizer(true, WithClock(FixedClock(base)))
+	targets := []string{"3天前", "周一", "明年"}
+	expectPoints := []time.Time{
+		time.Date(2019, 3, 3, 0, 0, 0, 0, loc),
+		time.Date(2019, 3, 11, 0, 0, 0, 0, loc),
+		time.Date(2020, 1, 1, 0, 0, 0, 0, loc),
+	}
+	for idx, target := range targets {
+		t.Log(target)
+		ret, err := normalizer.ParseNow(target)
+		if err != nil {
+			t.Error(err)
+		} else if len(ret.Points) != 1 {
+			t.Errorf("expect: 1 points, result: %d points", len(ret.Points))
+		} else if !ret.Points[0].Time.Equal(expectPoints[idx]) {
+			t.Errorf("expect: %v, got: %v", expectPoints[idx], ret.Points[0])
+		}
+	}
+}
+
+const longText1 = `7月 10日晚上7 点左右，六安市公安局裕安分局平桥派出所接到辖区居民戴某报警称，到同学家玩耍的女儿迟迟未归，手机也打不通了。很快，派出所又接到与戴某同住一小区的王女士报警：下午5点左右，12岁的儿子和同学在家中吃过晚饭后，带着3 岁的弟弟一起出了门，之后便没了消息，手机也关机了。短时间内，接到两起孩子失联的报警，值班民警张晖和队友立即前往小区。`
+
+const longText2 = `《辽宁日报》今日报道，7月18日辽宁召开省委常委扩大会，会议从下午两点半开到六点半，主要议题为：落实中央巡视整改要求。`
+
+// BenchmarkLongText1 长文字解析
+func BenchmarkLongText1(b *testing.B) {
+	normalizer := NewTimeNormalizer(false)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(longText1)))
+	for i := 0; i < b.N; i++ {
+		if _, err := normalizer.Parse(longText1, timeBase); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLongText2 长文字解析
+func BenchmarkLongText2(b *testing.B) {
+	normalizer := NewTimeNormalizer(false)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(longText2)))
+	for i := 0; i < b.N; i++ {
+		if _, err := normalizer.Parse(longText2, timeBase); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParallel 多个goroutine共享TimeNormalizer
+func BenchmarkParallel(b *testing.B) {
+	normalizer := NewTimeNormalizer(true)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := normalizer.Parse(longText1, timeBase); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestOptions 测试配置项
+func TestOptions(t *testing.T) {
+	tomorrow := timeBase.AddDate(0, 0, 1)
+	sunday := timeBase.AddDate(0, 0, int(7-timeBase.Weekday()))
+	cases := []struct {
+		Target     string
+		Normalizer *TimeNormalizer
+		Expect     time.Time
+	}{
+		{
+			Target:     "本周日",
+			Normalizer: New(WithWeekStart(time.Monday)),
+			Expect:     time.Date(sunday.Year(), sunday.Month(), sunday.Day(), 0, 0, 0, 0, loc),
+		},
+		{
+			Target:     "45年5月",
+			Normalizer: New(WithTwoDigitYearPivot(50)),
+			Expect:     time.Date(2045, 5, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			Target:     "明天下午",
+			Normalizer: New(WithDayPeriodDefaults(map[RangeTimeEnum]int{AFTERNOON: 14})),
+			Expect:     time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 14, 0, 0, 0, loc),
+		},
+		{
+			Target:     "明天",
+			Normalizer: New(WithFillPolicy(FillBase)),
+			Expect:     time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), timeBase.Hour(), timeBase.Minute(), timeBase.Second(), 0, loc),
+		},
+		{
+			Target:     "明天早上",
+			Normalizer: NewTimeNormalizer(true, WithLocation(time.UTC)),
+			Expect:     time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), int(EARLY_MORNING), 0, 0, 0, time.UTC),
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := c.Normalizer.Parse(c.Target, timeBase)
+		if err != nil {
+			t.Error(err)
+		} else if len(ret.Points) != 1 {
+			t.Errorf("expect: 1 points, result: %d points", len(ret.Points))
+		} else if !ret.Points[0].Time.Equal(c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, ret.Points[0])
+		}
+	}
+}
+
+// TestParseContext 测试取消、超时与输入长度限制
+func TestParseContext(t *testing.T) {
+	normalizer := New(WithMaxInputLength(20))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := normalizer.ParseContext(ctx, "明天早上跑步", timeBase); !errors.Is(err, context.Canceled) {
+		t.Errorf("expect: %v, got: %v", context.Canceled, err)
+	}
+	var tooLong *InputTooLongError
+	if _, err := normalizer.Parse(longText2, timeBase); !errors.Is(err, ErrInputTooLong) || !errors.As(err, &tooLong) {
+		t.Errorf("expect: %v, got: %v", ErrInputTooLong, err)
+	} else if tooLong.Max != 20 {
+		t.Errorf("expect: 20, got: %d", tooLong.Max)
+	}
+	target := strings.Repeat("某", 200000)
+	if _, err := New(WithMatchTimeout(time.Millisecond)).Parse(target, timeBase); !errors.Is(err, ErrMatchTimeout) {
+		t.Errorf("expect: %v, got: %v", ErrMatchTimeout, err)
+	}
+	if _, err := normalizer.ParseContext(context.Background(), "明天早上跑步", timeBase); err != nil {
+		t.Error(err)
+	}
+	// 预处理阶段的正则同样受超时时间限制
+	digits := strings.Repeat("9", 1000000)
+	m := newMatcher(context.Background(), time.Millisecond)
+	StringPreHandler{matcher: m}.numberTranslate(newTrackedString(digits))
+	if err := m.matchErr(); !errors.Is(err, ErrMatchTimeout) {
+		t.Errorf("expect: %v, got: %v", ErrMatchTimeout, err)
+	}
+	if _, err := New(WithMatchTimeout(time.Millisecond)).Parse(digits, timeBase); !errors.Is(err, ErrMatchTimeout) {
+		t.Errorf("expect: %v, got: %v", ErrMatchTimeout, err)
+	}
+	// 不同的超时时间共用有限个正则副本
+	for i := 1; i <= 1000; i++ {
+		newMatcher(context.Background(), time.Duration(i)*time.Millisecond+time.Duration(i))
+	}
+	newMatcher(context.Background(), 24*time.Hour)
+	var sets int
+	patternSets.Range(func(_, _ any) bool {
+		sets++
+		return true
+	})
+	if sets > maxTimeoutBucket+1 {
+		t.Errorf("expect: at most %d pattern sets, got: %d", maxTimeoutBucket+1, sets)
+	}
+	// 只有regexp2的超时错误转为ErrMatchTimeout
+	m = newMatcher(context.Background(), time.Millisecond)
+	m.fail(errors.New("bad pattern"))
+	if err := m.matchErr(); err == nil || errors.Is(err, ErrMatchTimeout) {
+		t.Errorf("expect: non-timeout error, got: %v", err)
+	}
+	// 没有设置超时时间时，单次匹配的时间以ctx的截止时间为上限
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := New(WithMatchTimeout(0)).ParseContext(ctx, digits, timeBase); !errors.Is(err, ErrMatchTimeout) {
+		t.Errorf("expect: %v, got: %v", ErrMatchTimeout, err)
+	}
+}
+
+// TestErrors 测试错误类型
+func TestErrors(t *testing.T) {
+	normalizer := NewTimeNormalizer(false)
+	cases := []struct {
+		Target string
+		Expect error
+	}{
+		{Target: "没有时间", Expect: ErrNoMatch},
+		{Target: "3000年春节", Expect: ErrOutOfRange},
+		{Target: "99999999999999999999天后", Expect: ErrOutOfRange},
+		{Target: "99999999999小时", Expect: ErrOutOfRange},
+		{Target: "2月30日", Expect: ErrInvalidDate},
+		{Target: "25点", Expect: ErrInvalidDate},
+		{Target: "每9999999999999天", Expect: ErrOutOfRange},
+		{Target: "连续99999999999次每天", Expect: ErrOutOfRange},
+		{Target: "每隔0天", Expect: ErrOutOfRange},
+		{Target: "每年农历13月初1", Expect: ErrOutOfRange},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		if _, err := normalizer.Parse(c.Target, timeBase); !errors.Is(err, c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, err)
+		}
+	}
+	var rangeErr *RangeError
+	if _, err := normalizer.Parse("1800年中秋", timeBase); !errors.As(err, &rangeErr) {
+		t.Errorf("expect: RangeError, got: %v", err)
+	} else if rangeErr.Min != 1888 || rangeErr.Max != 2111 {
+		t.Errorf("expect: [1888, 2111], got: [%d, %d]", rangeErr.Min, rangeErr.Max)
+	}
+	if _, err := normalizer.Parse("第99999999999999999999个周五", timeBase); !errors.As(err, &rangeErr) {
+		t.Errorf("expect: RangeError, got: %v", err)
+	} else if rangeErr.Field != "ordinal" || rangeErr.Min != 1 || rangeErr.Max != 366 {
+		t.Errorf("expect: ordinal [1, 366], got: %s [%d, %d]", rangeErr.Field, rangeErr.Min, rangeErr.Max)
+	}
+	// 没有指明月份时取之后第一个有该日的月份，指明月份的才是不存在的日期
+	september := time.Date(2025, 9, 20, 10, 0, 0, 0, loc)
+	if ret, err := NewTimeNormalizer(true).Parse("31号", september); err != nil {
+		t.Error(err)
+	} else if expect := time.Date(2025, 10, 31, 0, 0, 0, 0, loc); !ret.Points[0].Time.Equal(expect) {
+		t.Errorf("expect: %v, got: %v", expect, ret.Points[0].Time)
+	}
+	if _, err := NewTimeNormalizer(true).Parse("9月31号", september); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("expect: %v, got: %v", ErrInvalidDate, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := normalizer.ParseContext(ctx, "明天", timeBase); !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expect: %v, got: %v", ErrCanceled, err)
+	}
+}
+
+// TestSpan 测试匹配位置对应原始输入
+func TestSpan(t *testing.T) {
+	normalizer := NewTimeNormalizer(false)
+	cases := []struct {
+		Target string
+		Expect []Span
+	}{
+		{
+			Target: "我们 明天 下午三点的会议",
+			Expect: []Span{{Pos: 3, Length: 7, BytePos: 7, ByteLength: 19, Text: "明天 下午三点"}},
+		},
+		{
+			Target: "明天傍晚 开会",
+			Expect: []Span{{Pos: 0, Length: 4, BytePos: 0, ByteLength: 12, Text: "明天傍晚"}},
+		},
+		{
+			Target: "下周三和下周五",
+			Expect: []Span{
+				{Pos: 0, Length: 3, BytePos: 0, ByteLength: 9, Text: "下周三"},
+				{Pos: 4, Length: 3, BytePos: 12, ByteLength: 9, Text: "下周五"},
+			},
+		},
+		{
+			Target: "Hi, 三点十五分出发",
+			Expect: []Span{{Pos: 4, Length: 5, BytePos: 4, ByteLength: 15, Text: "三点十五分"}},
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, timeBase)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var spans []Span
+		for _, p := range ret.Points {
+			spans = append(spans, p.Span)
+		}
+		if !reflect.DeepEqual(spans, c.Expect) {
+			t.Errorf("expect: %+v, got: %+v", c.Expect, spans)
+		}
+	}
+}
+
+// TestExpressions 测试按表达式返回结果
+func TestExpressions(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	cases := []struct {
+		Target string
+		Kinds  []ExpressionKind
+		Texts  []string
+	}{
+		{Target: "周四下午三点到五点开会", Kinds: []ExpressionKind{INTERVAL}, Texts: []string{"周四下午三点到五点"}},
+		{Target: "我需要大概33天2分钟四秒", Kinds: []ExpressionKind{DURATION}, Texts: []string{"33天2分钟四秒"}},
+		{Target: "明天下午三点和后天早上", Kinds: []ExpressionKind{INSTANT, INSTANT}, Texts: []string{"明天下午三点", "后天早上"}},
+		{Target: "3月15日-4月2日", Kinds: []ExpressionKind{INTERVAL}, Texts: []string{"3月15日-4月2日"}},
+		{Target: longText1, Kinds: []ExpressionKind{INSTANT, INSTANT}},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, timeBase)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var (
+			kinds []ExpressionKind
+			texts []string
+		)
+		for _, e := range ret.Expressions {
+			kinds = append(kinds, e.Kind)
+			texts = append(texts, e.Text)
+		}
+		if !reflect.DeepEqual(kinds, c.Kinds) {
+			t.Errorf("expect: %v, got: %v", c.Kinds, kinds)
+		}
+		if c.Texts != nil && !reflect.DeepEqual(texts, c.Texts) {
+			t.Errorf("expect: %v, got: %v", c.Texts, texts)
+		}
+	}
+}
+
+// TestGranularity 测试时间精度和字段来源
+func TestGranularity(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	cases := []struct {
+		Target      string
+		Granularity []Granularity
+		Fields      []FieldSources
+	}{
+		{
+			Target:      "明天",
+			Granularity: []Granularity{DAY},
+			Fields:      []FieldSources{{FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT}},
+		},
+		{
+			Target:      "明天0点",
+			Granularity: []Granularity{HOUR},
+			Fields:      []FieldSources{{FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT}},
+		},
+		{
+			Target:      "5月1日",
+			Granularity: []Granularity{DAY},
+			Fields:      []FieldSources{{FIELD_BASE, FIELD_EXPLICIT, FIELD_EXPLICIT}},
+		},
+		{
+			Target:      "周四下午三点到五点开会",
+			Granularity: []Granularity{HOUR, HOUR},
+			Fields: []FieldSources{
+				{FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT},
+				{FIELD_CONTEXT, FIELD_CONTEXT, FIELD_CONTEXT, FIELD_EXPLICIT},
+			},
+		},
+		{
+			Target:      "今年儿童节晚上九点一刻",
+			Granularity: []Granularity{MINUTE},
+			Fields:      []FieldSources{{FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT, FIELD_EXPLICIT}},
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, timeBase)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var (
+			granularity []Granularity
+			fields      []FieldSources
+		)
+		for _, p := range ret.Points {
+			granularity = append(granularity, p.Granularity)
+			fields = append(fields, p.Fields)
+		}
+		if !reflect.DeepEqual(granularity, c.Granularity) {
+			t.Errorf("expect: %v, got: %v", c.Granularity, granularity)
+		}
+		if !reflect.DeepEqual(fields, c.Fields) {
+			t.Errorf("expect: %q, got: %q", c.Fields, fields)
+		}
+	}
+}
+
+// TestInterval 测试时间区间
+func TestInterval(t *testing.T) {
+	date := func(year int, month time.Month, day int, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+	cases := []struct {
+		Target string
+		Opts   []Option
+		Expect Interval
+	}{
+		{Target: "明天", Expect: Interval{Start: date(2025, 6, 19, 0), End: date(2025, 6, 20, 0)}},
+		{Target: "2025年", Expect: Interval{Start: date(2025, 1, 1, 0), End: date(2026, 1, 1, 0)}},
+		{Target: "3月", Expect: Interval{Start: date(2026, 3, 1, 0), End: date(2026, 4, 1, 0)}},
+		{Target: "明天下午", Expect: Interval{Start: date(2025, 6, 19, 12), End: date(2025, 6, 19, 18)}},
+		{Target: "下午3点", Expect: Interval{Start: date(2025, 6, 18, 15), End: date(2025, 6, 18, 16)}},
+		{Target: "3月15日到4月2日", Expect: Interval{Start: date(2026, 3, 15, 0), End: date(2026, 4, 3, 0)}},
+		{
+			Target: "明天下午",
+			Opts:   []Option{WithDayPeriodWindows(map[RangeTimeEnum]DayPeriodWindow{AFTERNOON: {Start: 13 * time.Hour, End: 17 * time.Hour}})},
+			Expect: Interval{Start: date(2025, 6, 19, 13), End: date(2025, 6, 19, 17)},
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := NewTimeNormalizer(true, c.Opts...).Parse(c.Target, timeBase)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ret.Expressions) != 1 {
+			t.Errorf("expect: 1 expressions, got: %d expressions", len(ret.Expressions))
+			continue
+		}
+		if got := ret.Expressions[0].Interval; !got.Start.Equal(c.Expect.Start) || !got.End.Equal(c.Expect.End) || got.EndInclusive {
+			t.Errorf("expect: %v, got: %v", c.Expect, got)
+		}
+	}
+	interval := Interval{Start: date(2025, 6, 19, 0), End: date(2025, 6, 20, 0)}
+	if inclusive := interval.Inclusive(); !inclusive.EndInclusive || !inclusive.Contains(inclusive.End) || inclusive.Contains(interval.End) {
+		t.Errorf("expect: inclusive interval, got: %v", inclusive)
+	}
+}
+
+// TestDuration 测试按日历计算的时间长度
+func TestDuration(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2024, 1, 31, 10, 0, 0, 0, loc)
+	cases := []struct {
+		Target   string
+		Duration Duration
+		Expect   time.Time
+		Exact    time.Duration
+	}{
+		{Target: "3个月", Duration: Duration{Months: 3}, Expect: base.AddDate(0, 3, 0)},
+		{Target: "1年", Duration: Duration{Years: 1}, Expect: time.Date(2025, 1, 31, 10, 0, 0, 0, loc)},
+		{Target: "2周3天", Duration: Duration{Weeks: 2, Days: 3}, Expect: time.Date(2024, 2, 17, 10, 0, 0, 0, loc), Exact: 17 * 24 * time.Hour},
+		{Target: "我需要大概33天2分钟四秒", Duration: Duration{Days: 33, Minutes: 2, Seconds: 4}, Expect: time.Date(2024, 3, 4, 10, 2, 4, 0, loc), Exact: 33*24*time.Hour + 2*time.Minute + 4*time.Second},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ret.Type != DELTA || len(ret.Points) != 1 || ret.Points[0].Duration == nil {
+			t.Errorf("expect: 1 %s point with duration, got: %+v", DELTA, ret)
+			continue
+		}
+		point := ret.Points[0]
+		if *point.Duration != c.Duration {
+			t.Errorf("expect: %+v, got: %+v", c.Duration, *point.Duration)
+		}
+		if !point.Time.Equal(c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, point.Time)
+		}
+		exact, ok := point.Duration.Exact()
+		if ok != (c.Exact != 0) || exact != c.Exact {
+			t.Errorf("expect: %v, got: %v", c.Exact, exact)
+		}
+	}
+}
+
+// TestJSON 测试JSON编码
+func TestJSON(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	cases := []struct {
+		Target   string
+		Contains []string
+	}{
+		{
+			Target: "我需要大概33天2分钟四秒",
+			Contains: []string{
+				`"schema_version":"1"`,
+				`"time":"2025-07-21T10:02:04+08:00"`,
+				`"duration":"P33DT2M4S"`,
+				`"interval":"2025-06-18T10:00:00+08:00/2025-07-21T10:02:04+08:00"`,
+			},
+		},
+		{
+			Target: "周四下午三点到五点开会",
+			Contains: []string{
+				`"kind":"interval"`,
+				`"granularity":"hour"`,
+				`"pos":0`,
+				`"text":"周四下午三点到五点"`,
+				`"interval":"2025-06-19T15:00:00+08:00/2025-06-19T18:00:00+08:00"`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, timeBase)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		data, err := json.Marshal(ret)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, v := range c.Contains {
+			if !strings.Contains(string(data), v) {
+				t.Errorf("expect: %s, got: %s", v, data)
+			}
+		}
+		var decoded Result
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Error(err)
+			continue
+		}
+		if encoded, _ := json.Marshal(decoded); string(encoded) != string(data) {
+			t.Errorf("expect: %s, got: %s", data, encoded)
+		}
+	}
+	var decoded Result
+	if err := json.Unmarshal([]byte(`{"schema_version":"2"}`), &decoded); !errors.Is(err, ErrSchemaVersion) {
+		t.Errorf("expect: %v, got: %v", ErrSchemaVersion, err)
+	}
+	for _, v := range []string{"P", "PT", "P1DT", "1D", "P1H", "P1M-3D"} {
+		if _, err := ParseISODuration(v); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("%s expect: %v, got: %v", v, ErrInvalidFormat, err)
+		}
+	}
+	if d, err := ParseISODuration("P1Y2M3W4DT5H6M7S"); err != nil || d != (Duration{1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("expect: %v, got: %v, %v", Duration{1, 2, 3, 4, 5, 6, 7}, d, err)
+	}
+	for _, d := range []Duration{{Days: -3, Hours: -2}, {Months: 1, Days: 3}, {}} {
+		data, err := json.Marshal(d)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var decoded Duration
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != d {
+			t.Errorf("expect: %v, got: %v, %v", d, decoded, err)
+		}
+	}
+	if _, err := json.Marshal(Duration{Months: 1, Days: -3}); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("expect: %v, got: %v", ErrInvalidFormat, err)
+	}
+}
+
+// TestRecurrence 测试重复时间
+func TestRecurrence(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 0, 0, 0, loc)
+	cases := []struct {
+		Target         string
+		RRule          string
+		First          time.Time
+		ExceptHolidays bool
+	}{
+		{Target: "每周一上午九点", RRule: "FREQ=WEEKLY;BYDAY=MO;BYHOUR=9;BYMINUTE=0", First: time.Date(2025, 6, 23, 9, 0, 0, 0, loc)},
+		{Target: "每隔两天", RRule: "FREQ=DAILY;INTERVAL=2", First: time.Date(2025, 6, 18, 0, 0, 0, 0, loc)},
+		{Target: "每月15号", RRule: "FREQ=MONTHLY;BYMONTHDAY=15", First: time.Date(2025, 7, 15, 0, 0, 0, 0, loc)},
+		{Target: "工作日每天早上8点", RRule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;BYHOUR=8;BYMINUTE=0", First: time.Date(2025, 6, 19, 8, 0, 0, 0, loc)},
+		{Target: "连续三周每周一上午九点", RRule: "FREQ=WEEKLY;COUNT=3;BYDAY=MO;BYHOUR=9;BYMINUTE=0", First: time.Date(2025, 6, 23, 9, 0, 0, 0, loc)},
+		{Target: "每周二、四晚上7点", RRule: "FREQ=WEEKLY;BYDAY=TU,TH;BYHOUR=19;BYMINUTE=0", First: time.Date(2025, 6, 19, 19, 0, 0, 0, loc)},
+		{Target: "每天早上8点和晚上8点", RRule: "FREQ=DAILY;BYHOUR=8,20;BYMINUTE=0", First: time.Date(2025, 6, 18, 20, 0, 0, 0, loc)},
+		{Target: "每天早上8点节假日除外", RRule: "FREQ=DAILY;BYHOUR=8;BYMINUTE=0", First: time.Date(2025, 6, 19, 8, 0, 0, 0, loc), ExceptHolidays: true},
+		{Target: "每年中秋", RRule: "RSCALE=CHINESE;FREQ=YEARLY;BYMONTH=8;BYMONTHDAY=15", First: time.Date(2025, 10, 6, 0, 0, 0, 0, loc)},
+		{Target: "每年国庆", RRule: "FREQ=YEARLY;BYMONTH=10;BYMONTHDAY=1", First: time.Date(2025, 10, 1, 0, 0, 0, 0, loc)},
+		{Target: "每年正月初一", RRule: "RSCALE=CHINESE;FREQ=YEARLY;BYMONTH=1;BYMONTHDAY=1", First: time.Date(2026, 2, 17, 0, 0, 0, 0, loc)},
+		{Target: "每年腊月30", RRule: "RSCALE=CHINESE;FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=30", First: time.Date(2030, 2, 2, 0, 0, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ret.Expressions) != 1 || ret.Expressions[0].Kind != RECURRENCE || ret.Expressions[0].Recurrence == nil {
+			t.Errorf("expect: 1 %s expression, got: %+v", RECURRENCE, ret.Expressions)
+			continue
+		}
+		rule := ret.Expressions[0].Recurrence
+		if got := rule.RRule(); got != c.RRule {
+			t.Errorf("expect: %s, got: %s", c.RRule, got)
+		}
+		if !rule.Start.Equal(c.First) {
+			t.Errorf("expect: %v, got: %v", c.First, rule.Start)
+		}
+		if rule.ExceptHolidays != c.ExceptHolidays {
+			t.Errorf("expect: %v, got: %v", c.ExceptHolidays, rule.ExceptHolidays)
+		}
+		parsed, err := ParseRRule(c.RRule)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got := parsed.RRule(); got != c.RRule {
+			t.Errorf("expect: %s, got: %s", c.RRule, got)
+		}
+	}
+	// 重复时间规则中的日期不按基准时间所在月份的天数检查
+	for target, expect := range map[string]struct {
+		RRule string
+		First time.Time
+	}{
+		"每月31号":   {RRule: "FREQ=MONTHLY;BYMONTHDAY=31", First: time.Date(2025, 10, 31, 0, 0, 0, 0, loc)},
+		"每年2月29号": {RRule: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", First: time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+	} {
+		ret, err := normalizer.Parse(target, time.Date(2025, 9, 20, 10, 0, 0, 0, loc))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if rule := ret.Expressions[0].Recurrence; rule == nil || rule.RRule() != expect.RRule || !rule.Start.Equal(expect.First) {
+			t.Errorf("expect: %s %v, got: %+v", expect.RRule, expect.First, ret.Expressions[0])
+		}
+	}
+	if _, err := normalizer.Parse("每年9月31号", base); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("expect: %v, got: %v", ErrInvalidDate, err)
+	}
+	// 每年重复且没有BYMONTH时，第几个星期几在全年范围内计算
+	for rrule, expect := range map[string]time.Time{
+		"FREQ=YEARLY;BYDAY=10MO": time.Date(2025, 3, 10, 0, 0, 0, 0, loc),
+		"FREQ=YEARLY;BYDAY=-1FR": time.Date(2025, 12, 26, 0, 0, 0, 0, loc),
+	} {
+		rule, err := ParseRRule(rrule)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		rule.Start = time.Date(2025, 1, 1, 0, 0, 0, 0, loc)
+		if got := normalizer.Schedule(rule).Between(rule.Start, time.Date(2026, 1, 1, 0, 0, 0, 0, loc)); len(got) != 1 || !got[0].Equal(expect) {
+			t.Errorf("expect: [%v], got: %v", expect, got)
+		}
+	}
+}
+
+// TestSchedule 测试重复时间的计算
+func TestSchedule(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	cases := []struct {
+		Target string
+		Base   time.Time
+		Opts   []Option
+		Expect []time.Time
+	}{
+		{
+			Target: "每周二、四晚上7点",
+			Base:   time.Date(2025, 6, 18, 10, 0, 0, 0, loc),
+			Expect: []time.Time{time.Date(2025, 6, 19, 19, 0, 0, 0, loc), time.Date(2025, 6, 24, 19, 0, 0, 0, loc), time.Date(2025, 6, 26, 19, 0, 0, 0, loc)},
+		},
+		{
+			Target: "每天早上8点节假日除外",
+			Base:   time.Date(2025, 9, 29, 10, 0, 0, 0, loc),
+			Expect: []time.Time{time.Date(2025, 9, 30, 8, 0, 0, 0, loc), time.Date(2025, 10, 4, 8, 0, 0, 0, loc), time.Date(2025, 10, 5, 8, 0, 0, 0, loc)},
+		},
+		{
+			Target: "每天凌晨2点半",
+			Base:   time.Date(2025, 3, 7, 10, 0, 0, 0, newYork),
+			Opts:   []Option{WithLocation(newYork)},
+			Expect: []time.Time{time.Date(2025, 3, 8, 2, 30, 0, 0, newYork), time.Date(2025, 3, 9, 3, 30, 0, 0, newYork), time.Date(2025, 3, 10, 2, 30, 0, 0, newYork)},
+		},
+		{
+			Target: "每天早上8点节假日除外",
+			Base:   time.Date(2025, 9, 29, 10, 0, 0, 0, loc),
+			Opts:   []Option{WithHolidayCalendar(HolidayDates(time.Date(2025, 9, 30, 0, 0, 0, 0, loc)))},
+			Expect: []time.Time{time.Date(2025, 10, 1, 8, 0, 0, 0, loc), time.Date(2025, 10, 2, 8, 0, 0, 0, loc), time.Date(2025, 10, 3, 8, 0, 0, 0, loc)},
+		},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := NewTimeNormalizer(true, c.Opts...).Parse(c.Target, c.Base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ret.Expressions) != 1 || ret.Expressions[0].Schedule == nil {
+			t.Errorf("expect: 1 schedule, got: %+v", ret.Expressions)
+			continue
+		}
+		schedule := ret.Expressions[0].Schedule
+		if next := schedule.Next(c.Base); !next.Equal(c.Expect[0]) {
+			t.Errorf("expect: %v, got: %v", c.Expect[0], next)
+		}
+		got := schedule.Between(c.Base, c.Expect[len(c.Expect)-1].Add(time.Second))
+		if len(got) != len(c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, got)
+			continue
+		}
+		for idx, v := range got {
+			if !v.Equal(c.Expect[idx]) {
+				t.Errorf("expect: %v, got: %v", c.Expect[idx], v)
+			}
+		}
+	}
+	limited, err := NewTimeNormalizer(true).Parse("连续三周每周一上午九点", time.Date(2025, 6, 18, 10, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := limited.Expressions[0].Schedule.Next(time.Date(2025, 7, 7, 9, 0, 0, 0, loc)); !next.IsZero() {
+		t.Errorf("expect: zero time, got: %v", next)
+	}
+	// 只有同一分句中的规则跳过节假日
+	clauses, err := NewTimeNormalizer(true).Parse("每天早上8点提醒。每周一开会，节假日除外", time.Date(2025, 9, 30, 10, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clauses.Expressions) != 2 {
+		t.Fatalf("expect: 2 expressions, got: %+v", clauses.Expressions)
+	}
+	if daily := clauses.Expressions[0]; daily.Recurrence.ExceptHolidays {
+		t.Errorf("expect: %v, got: %v", false, daily.Recurrence.ExceptHolidays)
+	} else if next, expect := daily.Schedule.Next(time.Date(2025, 9, 30, 10, 0, 0, 0, loc)), time.Date(2025, 10, 1, 8, 0, 0, 0, loc); !next.Equal(expect) {
+		t.Errorf("expect: %v, got: %v", expect, next)
+	}
+	if weekly := clauses.Expressions[1]; !weekly.Recurrence.ExceptHolidays {
+		t.Errorf("expect: %v, got: %v", true, weekly.Recurrence.ExceptHolidays)
+	}
+	// 跳过的节假日不计入次数，“节假日除外”属于重复时间表达式
+	target := "每周一，节假日除外，共10次"
+	excepted, err := NewTimeNormalizer(true).Parse(target, time.Date(2025, 9, 20, 10, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := excepted.Expressions[0]; e.Text != target {
+		t.Errorf("expect: %s, got: %s", target, e.Text)
+	} else if got := e.Schedule.Between(e.Recurrence.Start, time.Date(2026, 1, 1, 0, 0, 0, 0, loc)); len(got) != 10 {
+		t.Errorf("expect: 10 occurrences, got: %v", got)
+	} else if holiday := time.Date(2025, 10, 6, 0, 0, 0, 0, loc); got[2].Equal(holiday) {
+		t.Errorf("expect: %v skipped, got: %v", holiday, got)
+	}
+}
+
+// TestCron 测试cron表达式
+func TestCron(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 7, 0, 0, loc)
+	cases := []struct {
+		Target      string
+		Cron        string
+		WithSeconds string
+	}{
+		{Target: "每天凌晨3点", Cron: "0 3 * * *", WithSeconds: "0 0 3 * * *"},
+		{Target: "每周一到周五上午10点半", Cron: "30 10 * * 1,2,3,4,5", WithSeconds: "0 30 10 * * 1,2,3,4,5"},
+		{Target: "每月1号和15号", Cron: "0 0 1,15 * *", WithSeconds: "0 0 0 1,15 * *"},
+		{Target: "每年3月5日", Cron: "0 0 5 3 *", WithSeconds: "0 0 0 5 3 *"},
+		{Target: "每隔2小时", Cron: "0 */2 * * *", WithSeconds: "0 0 */2 * * *"},
+		{Target: "每隔15分钟", Cron: "7-59/15 * * * *", WithSeconds: "0 7-59/15 * * * *"},
+		{Target: "每秒", WithSeconds: "* * * * * *"},
+		{Target: "每隔三天"},
+		{Target: "每年中秋"},
+		{Target: "连续三周每周一上午九点"},
+		{Target: "每天早上8点节假日除外"},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ret.Expressions) != 1 || ret.Expressions[0].Recurrence == nil {
+			t.Errorf("expect: 1 %s expression, got: %+v", RECURRENCE, ret.Expressions)
+			continue
+		}
+		rule := ret.Expressions[0].Recurrence
+		for _, v := range []struct {
+			WithSeconds bool
+			Expect      string
+		}{{false, c.Cron}, {true, c.WithSeconds}} {
+			cron, err := rule.Cron(v.WithSeconds)
+			if v.Expect == "" {
+				var cronErr *CronError
+				if !errors.Is(err, ErrCronUnsupported) || !errors.As(err, &cronErr) {
+					t.Errorf("expect: %v, got: %q %v", ErrCronUnsupported, cron, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Error(err)
+			} else if cron != v.Expect {
+				t.Errorf("expect: %s, got: %s", v.Expect, cron)
+			}
+		}
+	}
+}
+
+// TestBaseRelated 测试以基准时间为参照的时间偏移
+func TestBaseRelated(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []struct {
+		Target      string
+		Expect      time.Time
+		Granularity Granularity
+	}{
+		{Target: "30分钟后", Expect: time.Date(2025, 6, 18, 10, 37, 0, 0, loc), Granularity: MINUTE},
+		{Target: "10秒前", Expect: time.Date(2025, 6, 18, 10, 6, 55, 0, loc), Granularity: SECOND},
+		{Target: "10秒钟以后", Expect: time.Date(2025, 6, 18, 10, 7, 15, 0, loc), Granularity: SECOND},
+		{Target: "两周以后", Expect: time.Date(2025, 7, 2, 0, 0, 0, 0, loc), Granularity: DAY},
+		{Target: "三个星期前", Expect: time.Date(2025, 5, 28, 0, 0, 0, 0, loc), Granularity: DAY},
+		{Target: "2个礼拜之前", Expect: time.Date(2025, 6, 4, 0, 0, 0, 0, loc), Granularity: DAY},
+		{Target: "一刻钟之后", Expect: time.Date(2025, 6, 18, 10, 22, 0, 0, loc), Granularity: MINUTE},
+		{Target: "半小时以前", Expect: time.Date(2025, 6, 18, 9, 37, 0, 0, loc), Granularity: MINUTE},
+		{Target: "1小时30分钟后", Expect: time.Date(2025, 6, 18, 11, 37, 0, 0, loc), Granularity: MINUTE},
+		{Target: "3天之后", Expect: time.Date(2025, 6, 21, 0, 0, 0, 0, loc), Granularity: DAY},
+		{Target: "2年以后", Expect: time.Date(2027, 1, 1, 0, 0, 0, 0, loc), Granularity: YEAR},
+		{Target: "2000年以前", Expect: time.Date(2000, 1, 1, 0, 0, 0, 0, loc), Granularity: YEAR},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ret.Type != TIMESTAMP || len(ret.Points) != 1 {
+			t.Errorf("expect: 1 %s point, got: %s %d points", TIMESTAMP, ret.Type, len(ret.Points))
+			continue
+		}
+		if !ret.Points[0].Time.Equal(c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, ret.Points[0].Time)
+		}
+		if ret.Points[0].Granularity != c.Granularity {
+			t.Errorf("expect: %s, got: %s", c.Granularity, ret.Points[0].Granularity)
+		}
+	}
+}
+
+// TestFraction 测试带小数或“半”的时间长度和时间偏移
+func TestFraction(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	spans := []struct {
+		Target   string
+		Duration Duration
+	}{
+		{Target: "一个半小时", Duration: Duration{Hours: 1, Minutes: 30}},
+		{Target: "两天半", Duration: Duration{Days: 2, Hours: 12}},
+		{Target: "半个月", Duration: Duration{Days: 15}},
+		{Target: "半年", Duration: Duration{Months: 6}},
+		{Target: "一年半", Duration: Duration{Years: 1, Months: 6}},
+		{Target: "1.5小时", Duration: Duration{Hours: 1, Minutes: 30}},
+		{Target: "三点五小时", Duration: Duration{Hours: 3, Minutes: 30}},
+		{Target: "大半天", Duration: Duration{Hours: 18}},
+		{Target: "2周半", Duration: Duration{Weeks: 2, Days: 3, Hours: 12}},
+	}
+	for _, c := range spans {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ret.Type != DELTA || len(ret.Points) != 1 || ret.Points[0].Duration == nil {
+			t.Errorf("expect: 1 %s point with duration, got: %+v", DELTA, ret)
+			continue
+		}
+		if *ret.Points[0].Duration != c.Duration {
+			t.Errorf("expect: %+v, got: %+v", c.Duration, *ret.Points[0].Duration)
+		}
+	}
+	offsets := []struct {
+		Target string
+		Expect time.Time
+	}{
+		{Target: "三点五", Expect: time.Date(2025, 6, 19, 3, 5, 0, 0, loc)},
+		{Target: "1个半小时后", Expect: time.Date(2025, 6, 18, 11, 37, 0, 0, loc)},
+		{Target: "2.5天后", Expect: time.Date(2025, 6, 20, 22, 0, 0, 0, loc)},
+		{Target: "半个月后", Expect: time.Date(2025, 7, 3, 0, 0, 0, 0, loc)},
+		{Target: "一年半以后", Expect: time.Date(2026, 12, 18, 0, 0, 0, 0, loc)},
+		{Target: "半年后", Expect: time.Date(2025, 12, 18, 0, 0, 0, 0, loc)},
+		{Target: "1.5年前", Expect: time.Date(2023, 12, 18, 0, 0, 0, 0, loc)},
+		{Target: "两个半月前", Expect: time.Date(2025, 4, 3, 0, 0, 0, 0, loc)},
+	}
+	for _, c := range offsets {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ret.Type != TIMESTAMP || len(ret.Points) != 1 {
+			t.Errorf("expect: 1 %s point, got: %s %d points", TIMESTAMP, ret.Type, len(ret.Points))
+		} else if !ret.Points[0].Time.Equal(c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, ret.Points[0].Time)
+		}
+	}
+}
+
+// intervalCase 解析结果为单个时间区间的测试用例
+type intervalCase struct {
+	Target string
+	Opts   []Option
+	Start  time.Time
+	End    time.Time
+}
+
+// checkIntervals 以base为基准时间解析cases，比较唯一表达式的时间区间
+func checkIntervals(t *testing.T, base time.Time, cases []intervalCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := NewTimeNormalizer(true, c.Opts...).Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ret.Expressions) != 1 {
+			t.Errorf("expect: 1 expression, got: %+v", ret.Expressions)
+			continue
+		}
+		if got := ret.Expressions[0].Interval; !got.Start.Equal(c.Start) || !got.End.Equal(c.End) {
+			t.Errorf("expect: %v-%v, got: %v-%v", c.Start, c.End, got.Start, got.End)
+		}
+	}
+}
+
+// TestMonthPart 测试上中下旬及月初、月底
+func TestMonthPart(t *testing.T) {
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []intervalCase{
+		{Target: "下个月上旬", Start: time.Date(2025, 7, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 11, 0, 0, 0, 0, loc)},
+		{Target: "上个月中旬", Start: time.Date(2025, 5, 11, 0, 0, 0, 0, loc), End: time.Date(2025, 5, 21, 0, 0, 0, 0, loc)},
+		{Target: "上上个月下旬", Start: time.Date(2025, 4, 21, 0, 0, 0, 0, loc), End: time.Date(2025, 5, 1, 0, 0, 0, 0, loc)},
+		{Target: "本月底", Start: time.Date(2025, 6, 26, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "下下个月月初", Start: time.Date(2025, 8, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 8, 6, 0, 0, 0, 0, loc)},
+		{Target: "2025年2月下旬", Start: time.Date(2025, 2, 21, 0, 0, 0, 0, loc), End: time.Date(2025, 3, 1, 0, 0, 0, 0, loc)},
+		{Target: "中旬", Start: time.Date(2025, 6, 11, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 21, 0, 0, 0, 0, loc)},
+		{Target: "月初", Start: time.Date(2025, 7, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 6, 0, 0, 0, 0, loc)},
+		{Target: "月底前交报告", Start: time.Date(2025, 6, 26, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+	ret, err := NewTimeNormalizer(true, WithMonthEdgeDays(3, 10)).Parse("下个月底", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := Interval{Start: time.Date(2025, 7, 22, 0, 0, 0, 0, loc), End: time.Date(2025, 8, 1, 0, 0, 0, 0, loc)}
+	if got := ret.Points[0].Interval; !got.Start.Equal(expect.Start) || !got.End.Equal(expect.End) {
+		t.Errorf("expect: %v, got: %v", expect, got)
+	}
+}
+
+// TestYearPart 测试上下半年及年初、年中、年底
+func TestYearPart(t *testing.T) {
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []intervalCase{
+		{Target: "明年上半年上线", Start: time.Date(2026, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "下半年", Start: time.Date(2025, 7, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "今年底", Start: time.Date(2025, 12, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "去年年底", Start: time.Date(2024, 12, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "2025年初", Start: time.Date(2025, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 2, 1, 0, 0, 0, 0, loc)},
+		{Target: "年初", Start: time.Date(2026, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 2, 1, 0, 0, 0, 0, loc)},
+		{Target: "明年年中", Start: time.Date(2026, 6, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 8, 1, 0, 0, 0, 0, loc)},
+		{Target: "去年前三季度", Start: time.Date(2024, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2024, 10, 1, 0, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+}
+
+// TestQuarter 测试季度和财年
+func TestQuarter(t *testing.T) {
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []intervalCase{
+		{Target: "2025Q3", Start: time.Date(2025, 7, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 10, 1, 0, 0, 0, 0, loc)},
+		{Target: "一季度", Start: time.Date(2026, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 4, 1, 0, 0, 0, 0, loc)},
+		{Target: "明年第二季度", Start: time.Date(2026, 4, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "下季度", Start: time.Date(2025, 7, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 10, 1, 0, 0, 0, 0, loc)},
+		{Target: "上个季度末", Start: time.Date(2025, 3, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 4, 1, 0, 0, 0, 0, loc)},
+		{Target: "上上季度", Start: time.Date(2024, 10, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "2025财年", Start: time.Date(2025, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "Q4", Opts: []Option{WithFiscalYearStart(time.April)}, Start: time.Date(2026, 1, 1, 0, 0, 0, 0, loc), End: time.Date(2026, 4, 1, 0, 0, 0, 0, loc)},
+		{Target: "2025财年第一季度", Opts: []Option{WithFiscalYearStart(time.April)}, Start: time.Date(2025, 4, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "下个财年", Opts: []Option{WithFiscalYearStart(time.April)}, Start: time.Date(2026, 4, 1, 0, 0, 0, 0, loc), End: time.Date(2027, 4, 1, 0, 0, 0, 0, loc)},
+		{Target: "去年前三季度", Opts: []Option{WithFiscalYearStart(time.April)}, Start: time.Date(2024, 4, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 1, 1, 0, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+}
+
+// TestWeekRange 测试整周、周末及工作日区间
+func TestWeekRange(t *testing.T) {
+	// 2025-06-18为周三
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	monday := []Option{WithWeekStart(time.Monday)}
+	cases := []intervalCase{
+		{Target: "本周", Start: time.Date(2025, 6, 15, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 22, 0, 0, 0, 0, loc)},
+		{Target: "本周", Opts: monday, Start: time.Date(2025, 6, 16, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 23, 0, 0, 0, 0, loc)},
+		{Target: "下周", Opts: monday, Start: time.Date(2025, 6, 23, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 30, 0, 0, 0, 0, loc)},
+		{Target: "上上周", Opts: monday, Start: time.Date(2025, 6, 2, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 9, 0, 0, 0, 0, loc)},
+		{Target: "下个礼拜", Opts: monday, Start: time.Date(2025, 6, 23, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 30, 0, 0, 0, 0, loc)},
+		{Target: "周末", Start: time.Date(2025, 6, 21, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 23, 0, 0, 0, 0, loc)},
+		{Target: "下周末", Opts: monday, Start: time.Date(2025, 6, 28, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 30, 0, 0, 0, 0, loc)},
+		{Target: "下周工作日", Start: time.Date(2025, 6, 23, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 28, 0, 0, 0, 0, loc)},
+		{Target: "本周内", Start: time.Date(2025, 6, 18, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 22, 0, 0, 0, 0, loc)},
+		{Target: "本周内", Opts: monday, Start: time.Date(2025, 6, 18, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 23, 0, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+}
+
+// TestWeekOrdinal 测试某年或某月的第n周
+func TestWeekOrdinal(t *testing.T) {
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []intervalCase{
+		{Target: "2025年第35周", Start: time.Date(2025, 8, 25, 0, 0, 0, 0, loc), End: time.Date(2025, 9, 1, 0, 0, 0, 0, loc)},
+		{Target: "2026年第1周", Start: time.Date(2025, 12, 29, 0, 0, 0, 0, loc), End: time.Date(2026, 1, 5, 0, 0, 0, 0, loc)},
+		{Target: "2020年第53周", Start: time.Date(2020, 12, 28, 0, 0, 0, 0, loc), End: time.Date(2021, 1, 4, 0, 0, 0, 0, loc)},
+		{Target: "2025年11月第三周", Start: time.Date(2025, 11, 15, 0, 0, 0, 0, loc), End: time.Date(2025, 11, 22, 0, 0, 0, 0, loc)},
+		{Target: "2025年6月第5周", Start: time.Date(2025, 6, 29, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "2025年11月第1周", Opts: []Option{WithWeekOfMonth(WeekOfMonthCalendar)}, Start: time.Date(2025, 11, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 11, 2, 0, 0, 0, 0, loc)},
+		{Target: "2025年11月第三周", Opts: []Option{WithWeekOfMonth(WeekOfMonthCalendar)}, Start: time.Date(2025, 11, 9, 0, 0, 0, 0, loc), End: time.Date(2025, 11, 16, 0, 0, 0, 0, loc)},
+		{Target: "2025年11月第1周", Opts: []Option{WithWeekOfMonth(WeekOfMonthISO)}, Start: time.Date(2025, 11, 3, 0, 0, 0, 0, loc), End: time.Date(2025, 11, 10, 0, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+	if _, err := NewTimeNormalizer(true).Parse("2025年第53周", base); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expect: %v, got: %v", ErrOutOfRange, err)
+	}
+	for target, expect := range map[time.Time]string{
+		time.Date(2025, 8, 25, 0, 0, 0, 0, loc):  "2025-W35",
+		time.Date(2024, 12, 30, 0, 0, 0, 0, loc): "2025-W01",
+		time.Date(2021, 1, 3, 0, 0, 0, 0, loc):   "2020-W53",
+	} {
+		if got := ISOWeekLabel(target); got != expect {
+			t.Errorf("expect: %s, got: %s", expect, got)
+		}
+	}
+}
+
+// TestWeekdayOrdinal 测试某月的第n个星期几
+func TestWeekdayOrdinal(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []struct {
+		Target string
+		Expect time.Time
+	}{
+		{Target: "五月的第二个星期日", Expect: time.Date(2026, 5, 10, 0, 0, 0, 0, loc)},
+		{Target: "11月第四个星期四", Expect: time.Date(2025, 11, 27, 0, 0, 0, 0, loc)},
+		{Target: "最后一个周五", Expect: time.Date(2025, 6, 27, 0, 0, 0, 0, loc)},
+		{Target: "倒数第二个周五", Expect: time.Date(2025, 6, 20, 0, 0, 0, 0, loc)},
+		{Target: "第二个星期日", Expect: time.Date(2025, 7, 13, 0, 0, 0, 0, loc)},
+		{Target: "下个月第一个工作日", Expect: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "10月第一个工作日", Expect: time.Date(2025, 10, 7, 0, 0, 0, 0, loc)},
+		{Target: "2025年9月最后一个工作日", Expect: time.Date(2025, 9, 30, 0, 0, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ret.Type != TIMESTAMP || len(ret.Points) != 1 {
+			t.Errorf("expect: 1 %s point, got: %s %d points", TIMESTAMP, ret.Type, len(ret.Points))
+		} else if !ret.Points[0].Time.Equal(c.Expect) {
+			t.Errorf("expect: %v, got: %v", c.Expect, ret.Points[0].Time)
+		}
+	}
+	if _, err := normalizer.Parse("2025年6月第6个周一", base); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expect: %v, got: %v", ErrOutOfRange, err)
+	}
+	recurrences := []struct {
+		Target string
+		RRule  string
+		First  time.Time
+	}{
+		{Target: "每月最后一个周五", RRule: "FREQ=MONTHLY;BYDAY=-1FR", First: time.Date(2025, 6, 27, 0, 0, 0, 0, loc)},
+		{Target: "每月第二个周日上午10点", RRule: "FREQ=MONTHLY;BYDAY=2SU;BYHOUR=10;BYMINUTE=0", First: time.Date(2025, 7, 13, 10, 0, 0, 0, loc)},
+		{Target: "每年11月第四个星期四", RRule: "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", First: time.Date(2025, 11, 27, 0, 0, 0, 0, loc)},
+	}
+	for _, c := range recurrences {
+		t.Log(c.Target)
+		ret, err := normalizer.Parse(c.Target, base)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(ret.Expressions) != 1 || ret.Expressions[0].Recurrence == nil {
+			t.Errorf("expect: 1 recurrence, got: %+v", ret.Expressions)
+			continue
+		}
+		rule := ret.Expressions[0].Recurrence
+		if got := rule.RRule(); got != c.RRule {
+			t.Errorf("expect: %s, got: %s", c.RRule, got)
+		}
+		if !rule.Start.Equal(c.First) {
+			t.Errorf("expect: %v, got: %v", c.First, rule.Start)
+		}
+	}
+}
+
+// TestPeriodOrdinal 测试月、季度、年等区间内的第几天及倒数第几天
+func TestPeriodOrdinal(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []intervalCase{
+		{Target: "本月最后一天", Start: time.Date(2025, 6, 30, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "下个月倒数第三天", Start: time.Date(2025, 7, 29, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 30, 0, 0, 0, 0, loc)},
+		{Target: "今年最后一个工作日", Start: time.Date(2025, 12, 31, 0, 0, 0, 0, loc), End: time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "年底前最后一周", Start: time.Date(2025, 12, 25, 0, 0, 0, 0, loc), End: time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "今年第100天", Start: time.Date(2025, 4, 10, 0, 0, 0, 0, loc), End: time.Date(2025, 4, 11, 0, 0, 0, 0, loc)},
+		{Target: "第100天", Start: time.Date(2026, 4, 10, 0, 0, 0, 0, loc), End: time.Date(2026, 4, 11, 0, 0, 0, 0, loc)},
+		{Target: "本季度最后一天", Start: time.Date(2025, 6, 30, 0, 0, 0, 0, loc), End: time.Date(2025, 7, 1, 0, 0, 0, 0, loc)},
+		{Target: "2024年第366天", Start: time.Date(2024, 12, 31, 0, 0, 0, 0, loc), End: time.Date(2025, 1, 1, 0, 0, 0, 0, loc)},
+		{Target: "下周最后一天", Start: time.Date(2025, 6, 28, 0, 0, 0, 0, loc), End: time.Date(2025, 6, 29, 0, 0, 0, 0, loc)},
+		{Target: "本月第3天上午9点", Start: time.Date(2025, 6, 3, 9, 0, 0, 0, loc), End: time.Date(2025, 6, 3, 10, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+	if _, err := normalizer.Parse("今年第400天", base); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expect: %v, got: %v", ErrOutOfRange, err)
+	}
+	ret, err := normalizer.Parse("每月最后一天", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ret.Expressions) != 1 || ret.Expressions[0].Recurrence == nil {
+		t.Fatalf("expect: 1 recurrence, got: %+v", ret.Expressions)
+	}
+	if got := ret.Expressions[0].Recurrence.RRule(); got != "FREQ=MONTHLY;BYMONTHDAY=-1" {
+		t.Errorf("expect: %s, got: %s", "FREQ=MONTHLY;BYMONTHDAY=-1", got)
+	}
+}
+
+// TestDoubleHour 测试时辰、刻和更
+func TestDoubleHour(t *testing.T) {
+	normalizer := NewTimeNormalizer(true)
+	base := time.Date(2025, 6, 18, 10, 7, 5, 0, loc)
+	cases := []intervalCase{
+		{Target: "子时", Start: time.Date(2025, 6, 18, 23, 0, 0, 0, loc), End: time.Date(2025, 6, 19, 1, 0, 0, 0, loc)},
+		{Target: "明天午时", Start: time.Date(2025, 6, 19, 11, 0, 0, 0, loc), End: time.Date(2025, 6, 19, 13, 0, 0, 0, loc)},
+		{Target: "午时三刻", Start: time.Date(2025, 6, 18, 11, 45, 0, 0, loc), End: time.Date(2025, 6, 18, 12, 0, 0, 0, loc)},
+		{Target: "2025年3月5日子时", Start: time.Date(2025, 3, 5, 23, 0, 0, 0, loc), End: time.Date(2025, 3, 6, 1, 0, 0, 0, loc)},
+		{Target: "明天子时", Start: time.Date(2025, 6, 19, 23, 0, 0, 0, loc), End: time.Date(2025, 6, 20, 1, 0, 0, 0, loc)},
+		{Target: "今晚亥时", Start: time.Date(2025, 6, 18, 21, 0, 0, 0, loc), End: time.Date(2025, 6, 18, 23, 0, 0, 0, loc)},
+		{Target: "午时，不见不散", Start: time.Date(2025, 6, 18, 11, 0, 0, 0, loc), End: time.Date(2025, 6, 18, 13, 0, 0, 0, loc)},
+		{Target: "三更半夜", Start: time.Date(2025, 6, 18, 23, 0, 0, 0, loc), End: time.Date(2025, 6, 19, 1, 0, 0, 0, loc)},
+		{Target: "五更", Start: time.Date(2025, 6, 19, 3, 0, 0, 0, loc), End: time.Date(2025, 6, 19, 5, 0, 0, 0, loc)},
+		{Target: "酉时到亥时", Start: time.Date(2025, 6, 18, 17, 0, 0, 0, loc), End: time.Date(2025, 6, 18, 23, 0, 0, 0, loc)},
+	}
+	checkIntervals(t, base, cases)
+	for _, target := range []string{"孩子时期", "电子时钟坏了", "他在重申时表示", "请填写生辰时间"} {
+		if ret, err := normalizer.Parse(target, base); err == nil {
+			t.Errorf("expect: error, got: %+v", ret.Expressions)
+		}
+	}
+}
+
+// FuzzParse 测试任意输入都不会panic
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"晚上8点到上午10点之间",
+		"2013年二月二十八日下午四点三十分二十九秒",
+		"我需要大概33天2分钟四秒",
+		"今年儿童节晚上九点一刻",
+		"大年初一",
+		"2020.10.1",
+		"1800年中秋",
+		"2月30日",
+		"6:30 起床",
+		"Hi，all.2025年11月第三周",
+		longText2,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	normalizer := NewTimeNormalizer(true)
+	f.Fuzz(func(t *testing.T, target string) {
+		ret, err := normalizer.Parse(target, timeBase)
+		if err == nil && len(ret.Points) == 0 {
+			t.Errorf("no points and no error for %q", target)
+		}
+	})
+}
@@ -0,0 +1,881 @@
+package timenlp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency 重复频率，与RFC 5545的FREQ一致
+type Frequency string
+
+const (
+	// SECONDLY 每秒
+	SECONDLY Frequency = "SECONDLY"
+	// MINUTELY 每分钟
+	MINUTELY Frequency = "MINUTELY"
+	// HOURLY 每小时
+	HOURLY Frequency = "HOURLY"
+	// DAILY 每天
+	DAILY Frequency = "DAILY"
+	// WEEKLY 每周
+	WEEKLY Frequency = "WEEKLY"
+	// MONTHLY 每月
+	MONTHLY Frequency = "MONTHLY"
+	// YEARLY 每年
+	YEARLY Frequency = "YEARLY"
+)
+
+// WeekdayNum 星期几，N不为0时表示每月（或每年）的第N个星期几，负数表示倒数第N个
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Recurrence 重复时间规则，字段含义与RFC 5545的RRULE一致
+type Recurrence struct {
+	// Freq 重复频率
+	Freq Frequency
+	// Interval 重复间隔，如“每隔2天”为2
+	Interval int
+	// ByMonth 月份
+	ByMonth []int
+	// ByMonthDay 每月的第几天，负数表示倒数第几天
+	ByMonthDay []int
+	// ByDay 星期几
+	ByDay []WeekdayNum
+	// ByHour 小时
+	ByHour []int
+	// ByMinute 分钟
+	ByMinute []int
+	// BySecond 秒
+	BySecond []int
+	// Count 重复次数，0为不限
+	Count int
+	// Until 结束时间（包含），零值为不限
+	Until time.Time
+	// WeekStart 每周的第一天
+	WeekStart time.Weekday
+	// Start 第一次发生的时间，即DTSTART
+	Start time.Time
+	// ExceptHolidays 是否跳过法定节假日，如“节假日除外”
+	ExceptHolidays bool
+	// Lunar ByMonth、ByMonthDay是否为农历月、日，如“每年中秋”，对应RFC 7529的RSCALE=CHINESE
+	Lunar bool
+}
+
+// rruleWeekdays RRULE中星期几的缩写
+var rruleWeekdays = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// RRule 转换为RFC 5545的RRULE值，如"FREQ=WEEKLY;BYDAY=MO;BYHOUR=9;BYMINUTE=0"
+// DTSTART见Start，UNTIL为UTC时间
+func (r Recurrence) RRule() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Lunar {
+		parts = append([]string{"RSCALE=CHINESE"}, parts...)
+	}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	join := func(key string, values []int) {
+		if len(values) == 0 {
+			return
+		}
+		strs := make([]string, 0, len(values))
+		for _, v := range values {
+			strs = append(strs, strconv.Itoa(v))
+		}
+		parts = append(parts, key+"="+strings.Join(strs, ","))
+	}
+	join("BYMONTH", r.ByMonth)
+	join("BYMONTHDAY", r.ByMonthDay)
+	if len(r.ByDay) > 0 {
+		strs := make([]string, 0, len(r.ByDay))
+		for _, v := range r.ByDay {
+			str := rruleWeekdays[v.Weekday]
+			if v.N != 0 {
+				str = strconv.Itoa(v.N) + str
+			}
+			strs = append(strs, str)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(strs, ","))
+	}
+	join("BYHOUR", r.ByHour)
+	join("BYMINUTE", r.ByMinute)
+	join("BYSECOND", r.BySecond)
+	if r.Freq == WEEKLY && r.Interval > 1 {
+		parts = append(parts, "WKST="+rruleWeekdays[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// rruleByDayPattern RRULE中BYDAY的取值
+var rruleByDayPattern = regexp.MustCompile(`^([+-]?\d{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+// ParseRRule 解析RFC 5545的RRULE值，可带"RRULE:"前缀，不包含Start和ExceptHolidays
+func ParseRRule(rrule string) (Recurrence, error) {
+	ret := Recurrence{Interval: 1, WeekStart: time.Monday}
+	invalid := func() (Recurrence, error) {
+		return Recurrence{}, fmt.Errorf("%w: rrule %q", ErrInvalidFormat, rrule)
+	}
+	ints := func(str string) ([]int, bool) {
+		var values []int
+		for _, v := range strings.Split(str, ",") {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, false
+			}
+			values = append(values, i)
+		}
+		return values, true
+	}
+	weekday := func(str string) (time.Weekday, bool) {
+		for idx, v := range rruleWeekdays {
+			if v == str {
+				return time.Weekday(idx), true
+			}
+		}
+		return 0, false
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(rrule, "RRULE:"), ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return invalid()
+		}
+		var ok = true
+		switch kv[0] {
+		case "FREQ":
+			ret.Freq = Frequency(kv[1])
+			switch ret.Freq {
+			case SECONDLY, MINUTELY, HOURLY, DAILY, WEEKLY, MONTHLY, YEARLY:
+			default:
+				ok = false
+			}
+		case "INTERVAL":
+			var err error
+			ret.Interval, err = strconv.Atoi(kv[1])
+			ok = err == nil && ret.Interval > 0
+		case "COUNT":
+			var err error
+			ret.Count, err = strconv.Atoi(kv[1])
+			ok = err == nil && ret.Count >= 0
+		case "UNTIL":
+			var err error
+			if ret.Until, err = time.Parse("20060102T150405Z", kv[1]); err != nil {
+				ret.Until, err = time.Parse("20060102", kv[1])
+			}
+			ok = err == nil
+		case "BYMONTH":
+			ret.ByMonth, ok = ints(kv[1])
+		case "BYMONTHDAY":
+			ret.ByMonthDay, ok = ints(kv[1])
+		case "BYHOUR":
+			ret.ByHour, ok = ints(kv[1])
+		case "BYMINUTE":
+			ret.ByMinute, ok = ints(kv[1])
+		case "BYSECOND":
+			ret.BySecond, ok = ints(kv[1])
+		case "BYDAY":
+			for _, v := range strings.Split(kv[1], ",") {
+				match := rruleByDayPattern.FindStringSubmatch(v)
+				if match == nil {
+					return invalid()
+				}
+				day := WeekdayNum{}
+				day.Weekday, _ = weekday(match[2])
+				if match[1] != "" {
+					day.N, _ = strconv.Atoi(match[1])
+				}
+				ret.ByDay = append(ret.ByDay, day)
+			}
+		case "RSCALE":
+			switch kv[1] {
+			case "CHINESE":
+				ret.Lunar = true
+			case "GREGORIAN":
+			default:
+				ok = false
+			}
+		case "WKST":
+			ret.WeekStart, ok = weekday(kv[1])
+		default:
+			ok = false
+		}
+		if !ok {
+			return invalid()
+		}
+	}
+	if ret.Freq == "" {
+		return invalid()
+	}
+	return ret, nil
+}
+
+// maxOccurrencePeriods 计算重复时间时最多检查的周期数
+const maxOccurrencePeriods = 100000
+
+// occurrences 依次生成不早于after的重复时间，fn返回false时停止
+// 重复时间从Start开始计算，Count、Until按RFC 5545的规则限制，exclude不为nil时跳过其返回true的时间且不计入Count
+func (r Recurrence) occurrences(after time.Time, loc *time.Location, exclude func(time.Time) bool, fn func(time.Time) bool) {
+	if r.Start.IsZero() || after.Year() > maxYear {
+		return
+	}
+	if loc == nil {
+		loc = r.Start.Location()
+	}
+	start := r.Start.In(loc)
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	var count int
+	skip := r.skipPeriods(start, after.In(loc), interval)
+	for period := skip; period < skip+maxOccurrencePeriods; period++ {
+		candidates := r.expand(r.periodStart(start, period*interval), start, loc)
+		for _, v := range candidates {
+			if v.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && v.After(r.Until) {
+				return
+			}
+			if exclude != nil && exclude(v) {
+				continue
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return
+			}
+			if !v.Before(after) && !fn(v) {
+				return
+			}
+		}
+		if year := r.periodStart(start, period*interval).Year(); year > maxYear {
+			return
+		}
+	}
+}
+
+// skipPeriods 不限次数时after之前可以直接跳过的周期数，有Count时需要从头计数
+func (r Recurrence) skipPeriods(start time.Time, after time.Time, interval int) int {
+	if r.Count > 0 || !after.After(start) {
+		return 0
+	}
+	days := func() int {
+		from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		to := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+		return int(to.Sub(from) / (24 * time.Hour))
+	}
+	var n int
+	switch r.Freq {
+	case YEARLY:
+		n = after.Year() - start.Year()
+	case MONTHLY:
+		n = (after.Year()-start.Year())*12 + int(after.Month()) - int(start.Month())
+	case WEEKLY:
+		n = days() / 7
+	case DAILY:
+		n = days()
+	case HOURLY:
+		n = int((after.Unix() - start.Unix()) / 3600)
+	case MINUTELY:
+		n = int((after.Unix() - start.Unix()) / 60)
+	default:
+		n = int(after.Unix() - start.Unix())
+	}
+	// 留出一个周期的余量
+	if n = n/interval - 1; n < 0 {
+		return 0
+	}
+	return n
+}
+
+// periodStart 第n个周期的开始时间
+func (r Recurrence) periodStart(start time.Time, n int) time.Time {
+	loc := start.Location()
+	switch r.Freq {
+	case YEARLY:
+		return time.Date(start.Year()+n, 1, 1, 0, 0, 0, 0, loc)
+	case MONTHLY:
+		return time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, loc)
+	case WEEKLY:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		return time.Date(start.Year(), start.Month(), start.Day()-offset+7*n, 0, 0, 0, 0, loc)
+	case DAILY:
+		return time.Date(start.Year(), start.Month(), start.Day()+n, 0, 0, 0, 0, loc)
+	case HOURLY:
+		hour := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, loc)
+		return time.Unix(hour.Unix()+int64(n)*3600, 0).In(loc)
+	case MINUTELY:
+		return time.Unix(start.Truncate(time.Minute).Unix()+int64(n)*60, 0).In(loc)
+	default:
+		return time.Unix(start.Unix()+int64(n), 0).In(loc)
+	}
+}
+
+// expand 展开一个周期内的全部重复时间，按时间排序
+func (r Recurrence) expand(period time.Time, start time.Time, loc *time.Location) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case YEARLY:
+		if r.Lunar {
+			days = r.expandLunar(period.Year(), loc)
+			break
+		}
+		months := r.ByMonth
+		if len(months) == 0 {
+			if len(r.ByMonthDay) > 0 || len(r.ByDay) > 0 {
+				months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			} else {
+				months = []int{int(start.Month())}
+			}
+		}
+		for _, month := range months {
+			days = append(days, r.expandMonth(period.Year(), time.Month(month), start, loc)...)
+		}
+	case MONTHLY:
+		for _, day := range r.expandMonth(period.Year(), period.Month(), start, loc) {
+			if r.matchMonth(day) {
+				days = append(days, day)
+			}
+		}
+	case WEEKLY:
+		for idx := 0; idx < 7; idx++ {
+			day := time.Date(period.Year(), period.Month(), period.Day()+idx, 0, 0, 0, 0, loc)
+			if r.matchMonth(day) && r.matchWeekday(day, start) {
+				days = append(days, day)
+			}
+		}
+	case DAILY:
+		if r.matchMonth(period) && r.matchMonthDay(period) && r.matchWeekday(period, period) {
+			days = append(days, period)
+		}
+	default:
+		if r.matchMonth(period) && r.matchMonthDay(period) && r.matchWeekday(period, period) && r.matchTime(period) {
+			return []time.Time{period}
+		}
+		return nil
+	}
+	var ret []time.Time
+	for _, day := range days {
+		for _, hour := range r.orDefault(r.ByHour, start.Hour()) {
+			for _, minute := range r.orDefault(r.ByMinute, start.Minute()) {
+				for _, second := range r.orDefault(r.BySecond, start.Second()) {
+					ret = append(ret, localTime(day.Year(), day.Month(), day.Day(), hour, minute, second, loc))
+				}
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Before(ret[j])
+	})
+	return ret
+}
+
+// expandLunar 展开农历year年中符合ByMonth、ByMonthDay的日期
+func (r Recurrence) expandLunar(year int, loc *time.Location) []time.Time {
+	var ret []time.Time
+	for _, month := range r.ByMonth {
+		for _, day := range r.ByMonthDay {
+			lunar := Lunar{Year: year, Month: month, Day: day}
+			// 小月没有30日，跳过
+			if !lunarSolarConverter.IsSupported(lunar) || day > lunarSolarConverter.MonthDays(lunar) {
+				continue
+			}
+			solar := lunarSolarConverter.LunarToSolar(lunar)
+			ret = append(ret, time.Date(solar.Year, time.Month(solar.Month), solar.Day, 0, 0, 0, 0, loc))
+		}
+	}
+	return ret
+}
+
+// expandMonth 展开某月中符合ByMonthDay、ByDay的日期
+func (r Recurrence) expandMonth(year int, month time.Month, start time.Time, loc *time.Location) []time.Time {
+	last := daysIn(year, month)
+	var ret []time.Time
+	for day := 1; day <= last; day++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			if day == start.Day() {
+				ret = append(ret, date)
+			}
+			continue
+		}
+		if r.matchMonthDay(date) && r.matchWeekday(date, date) {
+			ret = append(ret, date)
+		}
+	}
+	return ret
+}
+
+// localTime 当地时间，夏令时开始时不存在的时间按切换前的UTC偏移计算（RFC 5545），如2:30顺延为3:30
+func localTime(year int, month time.Month, day, hour, minute, second int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, minute, second, 0, loc)
+	if t.Hour() == hour && t.Minute() == minute && t.Second() == second {
+		return t
+	}
+	wall := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	_, offset := t.Add(-24 * time.Hour).Zone()
+	return time.Unix(wall.Unix()-int64(offset), 0).In(loc)
+}
+
+// matchMonth 是否符合ByMonth
+func (r Recurrence) matchMonth(t time.Time) bool {
+	return len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(t.Month()))
+}
+
+// matchMonthDay 是否符合ByMonthDay
+func (r Recurrence) matchMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(t.Year(), t.Month())
+	for _, v := range r.ByMonthDay {
+		if v == t.Day() || (v < 0 && last+v+1 == t.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday 是否符合ByDay，没有ByDay时每周重复的规则取start的星期几
+func (r Recurrence) matchWeekday(t time.Time, start time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return r.Freq != WEEKLY || t.Weekday() == start.Weekday()
+	}
+	for _, v := range r.ByDay {
+		if v.Weekday != t.Weekday() {
+			continue
+		}
+		if v.N == 0 || r.Freq == WEEKLY || r.Freq == DAILY {
+			return true
+		}
+		// 每年重复且没有ByMonth时在全年范围内计算第几个星期几
+		day, days := t.Day(), daysIn(t.Year(), t.Month())
+		if r.Freq == YEARLY && len(r.ByMonth) == 0 {
+			day, days = t.YearDay(), time.Date(t.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+		}
+		nth := (day-1)/7 + 1
+		last := -((days-day)/7 + 1)
+		if v.N == nth || v.N == last {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTime 时、分、秒是否符合ByHour、ByMinute、BySecond
+func (r Recurrence) matchTime(t time.Time) bool {
+	return (len(r.ByHour) == 0 || containsInt(r.ByHour, t.Hour())) &&
+		(len(r.ByMinute) == 0 || containsInt(r.ByMinute, t.Minute())) &&
+		(len(r.BySecond) == 0 || containsInt(r.BySecond, t.Second()))
+}
+
+// orDefault values为空时返回def
+func (r Recurrence) orDefault(values []int, def int) []int {
+	if len(values) == 0 {
+		return []int{def}
+	}
+	return values
+}
+
+// containsInt 是否包含v
+func containsInt(values []int, v int) bool {
+	for _, i := range values {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// recurrenceMatch 文本中识别到的重复时间规则
+type recurrenceMatch struct {
+	// start, end 规则在标准化后字符串中的字符区间
+	start int
+	end   int
+	// count 规则的次数或持续时间，如“连续3周”
+	count int
+	// countUnit count的单位，为空时表示次数
+	countUnit Frequency
+	// countEnd 规则之后的次数或持续时间的结束位置
+	countEnd int
+	// skips 规则中的间隔和次数，不应识别为时间长度
+	skips [][2]int
+	// except 同一分句中跳过节假日的说明的字符区间，如“节假日除外”，没有时为空区间
+	except [2]int
+	rule   Recurrence
+	// err 间隔、次数等超出范围的错误
+	err error
+}
+
+// maxRecurrenceNumber 重复时间规则中间隔、次数的最大值
+const maxRecurrenceNumber = 10000
+
+// atoi 转换间隔、次数等数字，无法转换或超出[min, max]时记录RangeError并返回min
+func (m *recurrenceMatch) atoi(field string, str string, min int, max int) int {
+	v, err := strconv.Atoi(str)
+	if err != nil || v < min || v > max {
+		if m.err == nil {
+			m.err = &RangeError{Field: field, Value: str, Min: min, Max: max}
+		}
+		return min
+	}
+	return v
+}
+
+var (
+	// recurrencePattern 重复时间规则：每隔2天、每2周、每周1到周5、每月1、15号、每年3月5日、工作日每天
+	recurrencePattern = regexp.MustCompile(`(?:(连续|共)(\d+)(?:个)?(天|日|周|星期|礼拜|月|年|次)(?:的)?)?(?:(工作日|周末)(?:的)?)?每(隔)?(\d+)?(?:个)?(工作日|周末|年|月|周|星期|礼拜|天|日|晚|早|小时|钟头|分钟|秒钟|秒)`)
+	// recurrenceWeekdayPattern 每周后的星期几列表
+	recurrenceWeekdayPattern = regexp.MustCompile(`^(?:周|星期|礼拜)?[1-7](?:(?:[、,，和及与/]|到|至|~|-)(?:周|星期|礼拜)?[1-7])*`)
+	// recurrenceMonthDayPattern 每月后的日期列表
+	recurrenceMonthDayPattern = regexp.MustCompile(`^(?:(?:[1-9]|[12][0-9]|3[01])[号日]?(?:[、,，和及与]|到|至|~|-))*(?:[1-9]|[12][0-9]|3[01])[号日]`)
+	// recurrenceYearDayPattern 每年后的月、日
+	recurrenceYearDayPattern = regexp.MustCompile(`^((?:1[0-2])|[1-9])月(?:((?:[12][0-9])|(?:3[01])|[1-9])[号日])?`)
+	// recurrenceNthWeekdayPattern 每月或每年某月后的第几个星期几，如“最后1个周5”
+	recurrenceNthWeekdayPattern = regexp.MustCompile(`^(?:(倒数)?第(\d+)个|最后1?个)(?:周|星期|礼拜)([1-7])`)
+	// recurrenceLastMonthDayPattern 每月后的倒数第几天，如“最后1天”、“倒数第3天”
+	recurrenceLastMonthDayPattern = regexp.MustCompile(`^(?:倒数第([1-9]|[12][0-9]|3[01])|最后1?)天`)
+	// recurrenceCountPattern 同一分句中重复时间之后的次数或持续时间，如“每周1上午9点，连续3周”
+	recurrenceCountPattern = regexp.MustCompile(`^[^。；;！!？?\n]{0,12}?((?:连续|共)(\d+)(?:个)?(天|日|周|星期|礼拜|月|年|次))`)
+	// recurrenceLunarPattern 每年后的农历日期，“正月”、“冬月”、“腊月”可以省略“农历”
+	recurrenceLunarPattern = regexp.MustCompile(`^(?:(?:农历|阴历)(?:(\d+|正|冬|腊)月初?(\d+)[号日]?)?|(正|冬|腊)月初?(\d+)[号日]?)`)
+	// recurrenceListPattern 列表中的数字及连接词
+	recurrenceListPattern = regexp.MustCompile(`\d+|到|至|~|-`)
+	// exceptHolidaysPattern 跳过节假日
+	exceptHolidaysPattern = regexp.MustCompile(`(法定)?节假日(除外|不算|休息|跳过)|(跳过|除了?|不含|不包括)(法定)?节假日`)
+	// clauseBreakPattern 分句之间的标点
+	clauseBreakPattern = regexp.MustCompile(`[。；;！!？?\n]`)
+)
+
+// recurrenceFrequencies 重复单位对应的频率
+var recurrenceFrequencies = map[string]Frequency{
+	"年":   YEARLY,
+	"月":   MONTHLY,
+	"周":   WEEKLY,
+	"星期":  WEEKLY,
+	"礼拜":  WEEKLY,
+	"工作日": WEEKLY,
+	"周末":  WEEKLY,
+	"天":   DAILY,
+	"日":   DAILY,
+	"晚":   DAILY,
+	"早":   DAILY,
+	"小时":  HOURLY,
+	"钟头":  HOURLY,
+	"分钟":  MINUTELY,
+	"秒钟":  SECONDLY,
+	"秒":   SECONDLY,
+	"次":   "",
+}
+
+// lunarMonths 农历月份的别称
+var lunarMonths = map[string]int{"正": 1, "冬": 11, "腊": 12}
+
+// workdays 工作日
+var workdays = []WeekdayNum{{Weekday: time.Monday}, {Weekday: time.Tuesday}, {Weekday: time.Wednesday}, {Weekday: time.Thursday}, {Weekday: time.Friday}}
+
+// weekendDays 周末
+var weekendDays = []WeekdayNum{{Weekday: time.Saturday}, {Weekday: time.Sunday}}
+
+// recurrences 识别标准化后字符串中的重复时间规则
+func (n *TimeNormalizer) recurrences(text string) []recurrenceMatch {
+	runes := []rune(text)
+	excepts := exceptHolidaysPattern.FindAllStringIndex(text, -1)
+	var ret []recurrenceMatch
+	for _, loc := range recurrencePattern.FindAllStringSubmatchIndex(text, -1) {
+		sub := func(idx int) string {
+			if loc[idx*2] < 0 {
+				return ""
+			}
+			return text[loc[idx*2]:loc[idx*2+1]]
+		}
+		unit := sub(7)
+		m := recurrenceMatch{
+			start: utf8Index(text, loc[0]),
+			end:   utf8Index(text, loc[1]),
+			rule: Recurrence{
+				Freq:      recurrenceFrequencies[unit],
+				Interval:  1,
+				WeekStart: n.weekStart,
+			},
+		}
+		if v := sub(6); v != "" {
+			m.rule.Interval = m.atoi("interval", v, 1, maxRecurrenceNumber)
+			m.skips = append(m.skips, [2]int{utf8Index(text, loc[12]), m.end})
+		}
+		if sub(2) != "" {
+			m.count = m.atoi("count", sub(2), 1, maxRecurrenceNumber)
+			m.countUnit = recurrenceFrequencies[sub(3)]
+			m.skips = append(m.skips, [2]int{m.start, utf8Index(text, loc[7])})
+		}
+		switch {
+		case unit == "工作日" || sub(4) == "工作日":
+			m.rule.ByDay = workdays
+		case unit == "周末" || sub(4) == "周末":
+			m.rule.ByDay = weekendDays
+		}
+		if m.rule.ByDay != nil && m.rule.Freq == DAILY && m.rule.Interval == 1 {
+			m.rule.Freq = WEEKLY
+		}
+		rest := text[loc[1]:]
+		var suffix int
+		switch m.rule.Freq {
+		case WEEKLY:
+			if days := recurrenceWeekdayPattern.FindString(rest); days != "" && m.rule.ByDay == nil {
+				for _, v := range parseRecurrenceList(days, 1, 7) {
+					m.rule.ByDay = append(m.rule.ByDay, WeekdayNum{Weekday: time.Weekday(v % 7)})
+				}
+				suffix = len(days)
+			}
+		case MONTHLY:
+			if days := recurrenceMonthDayPattern.FindString(rest); days != "" {
+				m.rule.ByMonthDay = parseRecurrenceList(days, 1, 31)
+				suffix = len(days)
+			} else if day, ok := nthWeekdayNum(rest); ok {
+				m.rule.ByDay = []WeekdayNum{day}
+				suffix = len(recurrenceNthWeekdayPattern.FindString(rest))
+			} else if match := recurrenceLastMonthDayPattern.FindStringSubmatch(rest); match != nil {
+				day := 1
+				if match[1] != "" {
+					day = m.atoi("last month day", match[1], 1, 31)
+				}
+				m.rule.ByMonthDay = []int{-day}
+				suffix = len(match[0])
+			}
+		case YEARLY:
+			if match := recurrenceLunarPattern.FindStringSubmatch(rest); match != nil {
+				m.rule.Lunar = true
+				month, day := match[1]+match[3], match[2]+match[4]
+				if month != "" {
+					if v, found := lunarMonths[month]; found {
+						m.rule.ByMonth = []int{v}
+					} else {
+						m.rule.ByMonth = []int{m.atoi("lunar month", month, 1, 12)}
+					}
+					m.rule.ByMonthDay = []int{m.atoi("lunar day", day, 1, 30)}
+				}
+				suffix = len(match[0])
+			} else if match := recurrenceYearDayPattern.FindStringSubmatch(rest); match != nil {
+				month, _ := strconv.Atoi(match[1])
+				m.rule.ByMonth = []int{month}
+				if match[2] != "" {
+					day, _ := strconv.Atoi(match[2])
+					m.rule.ByMonthDay = []int{day}
+				} else if day, ok := nthWeekdayNum(rest[len(match[0]):]); ok {
+					m.rule.ByDay = []WeekdayNum{day}
+					suffix = len(recurrenceNthWeekdayPattern.FindString(rest[len(match[0]):]))
+				}
+				suffix += len(match[0])
+			}
+		}
+		m.end += utf8Index(rest, suffix)
+		if m.count == 0 {
+			rest := string(runes[m.end:])
+			if match := recurrenceCountPattern.FindStringSubmatchIndex(rest); match != nil {
+				m.count = m.atoi("count", rest[match[4]:match[5]], 1, maxRecurrenceNumber)
+				m.countUnit = recurrenceFrequencies[rest[match[6]:match[7]]]
+				m.skips = append(m.skips, [2]int{m.end + utf8Index(rest, match[2]), m.end + utf8Index(rest, match[3])})
+				m.countEnd = m.end + utf8Index(rest, match[1])
+			}
+		}
+		m.except = exceptClause(runes, excepts, m)
+		// 只有同一分句中有“节假日除外”的规则跳过节假日
+		m.rule.ExceptHolidays = m.except[1] > m.except[0]
+		ret = append(ret, m)
+	}
+	return ret
+}
+
+// exceptClause 与重复时间规则m在同一分句中的跳过节假日的说明，excepts为其在text中的字节区间
+func exceptClause(runes []rune, excepts [][]int, m recurrenceMatch) [2]int {
+	text := string(runes)
+	for _, loc := range excepts {
+		start, stop := utf8Index(text, loc[0]), utf8Index(text, loc[1])
+		if (start >= m.end && !clauseBreakPattern.MatchString(string(runes[m.end:start]))) ||
+			(stop <= m.start && !clauseBreakPattern.MatchString(string(runes[stop:m.start]))) {
+			return [2]int{start, stop}
+		}
+	}
+	return [2]int{}
+}
+
+// nthWeekdayNum 解析str开头的第几个星期几，如“第2个周7”为每月第二个周日
+func nthWeekdayNum(str string) (WeekdayNum, bool) {
+	match := recurrenceNthWeekdayPattern.FindStringSubmatch(str)
+	if match == nil {
+		return WeekdayNum{}, false
+	}
+	nth := -1
+	if match[2] != "" {
+		nth, _ = strconv.Atoi(match[2])
+		if match[1] != "" {
+			nth = -nth
+		}
+	}
+	if nth == 0 || nth > 5 || nth < -5 {
+		return WeekdayNum{}, false
+	}
+	weekday, _ := strconv.Atoi(match[3])
+	return WeekdayNum{N: nth, Weekday: time.Weekday(weekday % 7)}, true
+}
+
+// parseRecurrenceList 解析“1、3、5”、“1到5”形式的数字列表
+func parseRecurrenceList(str string, min int, max int) []int {
+	var (
+		ret     []int
+		isRange bool
+	)
+	for _, token := range recurrenceListPattern.FindAllString(str, -1) {
+		v, err := strconv.Atoi(token)
+		if err != nil {
+			isRange = true
+			continue
+		}
+		if v < min || v > max {
+			continue
+		}
+		if isRange && len(ret) > 0 {
+			for i := ret[len(ret)-1] + 1; i <= v; i++ {
+				ret = append(ret, i)
+			}
+		} else {
+			ret = append(ret, v)
+		}
+		isRange = false
+	}
+	return ret
+}
+
+// utf8Index 字节位置转换为字符位置
+func utf8Index(str string, byteIdx int) int {
+	return len([]rune(str[:byteIdx]))
+}
+
+// recurrence 根据重复时间规则和规则中的时间单元生成Recurrence，ref为参考时间
+func (m recurrenceMatch) recurrence(units []TimeUnit, ref time.Time) Recurrence {
+	rule := m.rule
+	for _, unit := range units {
+		if unit.isTimeSpan || unit.sources[3] == FIELD_UNSET {
+			continue
+		}
+		if !containsInt(rule.ByHour, unit.tp[3]) {
+			rule.ByHour = append(rule.ByHour, unit.tp[3])
+		}
+		if rule.ByMinute == nil {
+			minute := 0
+			if unit.sources[4] != FIELD_UNSET {
+				minute = unit.tp[4]
+			}
+			rule.ByMinute = []int{minute}
+		}
+		if rule.BySecond == nil && unit.sources[5] != FIELD_UNSET {
+			rule.BySecond = []int{unit.tp[5]}
+		}
+	}
+	switch rule.Freq {
+	case HOURLY, MINUTELY, SECONDLY:
+		rule.ByHour, rule.ByMinute, rule.BySecond = nil, nil, nil
+	case YEARLY:
+		if len(rule.ByMonth) == 0 && len(rule.ByDay) == 0 {
+			rule.yearDay(units)
+		}
+		// 没有农历月、日时按公历每年重复
+		rule.Lunar = rule.Lunar && len(rule.ByMonth) > 0
+	}
+	sort.Ints(rule.ByHour)
+	// 没有具体时间的按天重复，当天也算作一次
+	start, after := ref.Truncate(time.Second), ref
+	switch rule.Freq {
+	case DAILY, WEEKLY, MONTHLY, YEARLY:
+		start = time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+		if len(rule.ByHour) == 0 {
+			after = start
+		}
+	}
+	rule.Start = start
+	var first time.Time
+	rule.occurrences(after, ref.Location(), nil, func(t time.Time) bool {
+		first = t
+		return false
+	})
+	if first.IsZero() {
+		return rule
+	}
+	rule.Start = first
+	if m.count > 0 {
+		perPeriod := len(rule.ByHour) <= 1 && len(rule.ByMonthDay) <= 1 && len(rule.ByDay) <= 1
+		if m.countUnit == "" || (m.countUnit == rule.Freq && perPeriod) {
+			rule.Count = m.count
+			if m.countUnit != "" && rule.Interval > 1 {
+				rule.Count = (m.count + rule.Interval - 1) / rule.Interval
+			}
+		} else {
+			var end time.Time
+			switch m.countUnit {
+			case YEARLY:
+				end = first.AddDate(m.count, 0, 0)
+			case MONTHLY:
+				end = first.AddDate(0, m.count, 0)
+			case WEEKLY:
+				end = first.AddDate(0, 0, 7*m.count)
+			default:
+				end = first.AddDate(0, 0, m.count)
+			}
+			rule.Until = end.Add(-time.Second)
+		}
+	}
+	return rule
+}
+
+// yearDay 每年重复的月、日取自时间单元，如“每年国庆”，农历节日按农历月、日重复
+func (r *Recurrence) yearDay(units []TimeUnit) {
+	for _, unit := range units {
+		if unit.isTimeSpan || unit.sources[1] != FIELD_EXPLICIT || unit.sources[2] != FIELD_EXPLICIT {
+			continue
+		}
+		r.ByMonth, r.ByMonthDay = []int{unit.tp[1]}, []int{unit.tp[2]}
+		holi := holidayPattern.FindString(unit.expTime)
+		if !strings.HasSuffix(holi, "节") {
+			holi += "节"
+		}
+		if date, found := unit.normalizer.holiLunar[holi]; found {
+			arr := strings.Split(date, "-")
+			month, _ := strconv.Atoi(arr[0])
+			day, _ := strconv.Atoi(arr[1])
+			r.ByMonth, r.ByMonthDay = []int{month}, []int{day}
+			r.Lunar = true
+		}
+		return
+	}
+}
+
+// granularity 重复时间每次发生的时间精度
+func (r Recurrence) granularity() Granularity {
+	switch r.Freq {
+	case SECONDLY:
+		return SECOND
+	case MINUTELY:
+		return MINUTE
+	case HOURLY:
+		return HOUR
+	}
+	if len(r.BySecond) > 0 {
+		return SECOND
+	} else if len(r.ByMinute) > 0 && r.ByMinute[0] != 0 {
+		return MINUTE
+	} else if len(r.ByHour) > 0 {
+		return HOUR
+	}
+	return DAY
+}
@@ -2,7 +2,7 @@ package timenlp
 
 import "time"
 
-// ResultType 返回值类型
+// ResultType 返回值类型，由全部时间点整体决定，单个表达式的类型见ExpressionKind
 type ResultType string
 
 const (
@@ -14,14 +14,35 @@ const (
 	TIMESTAMP ResultType = "timestamp"
 )
 
+// Span 时间表达式在原始输入中的位置
+type Span struct {
+	// Pos 文字位置（字符）
+	Pos int `json:"pos"`
+	// Length 文字长度（字符）
+	Length int `json:"length"`
+	// BytePos 文字位置（字节）
+	BytePos int `json:"byte_pos"`
+	// ByteLength 文字长度（字节）
+	ByteLength int `json:"byte_length"`
+	// Text 原始输入中对应的文字
+	Text string `json:"text,omitempty"`
+}
+
 // ResultPoint 返回值包含时间点
 type ResultPoint struct {
 	// Time 时间
-	Time time.Time
-	// Pos 文字位置
-	Pos int `json:"pos,omitempty"`
-	// Length 文字长度
-	Length int `json:"length,omitempty"`
+	Time time.Time `json:"time"`
+	// Span 时间表达式在原始输入中的位置
+	Span
+	// Granularity 时间精度，如“明天”为day，“明天0点”为hour，时间长度表达式为空
+	Granularity Granularity `json:"granularity,omitempty"`
+	// Fields 年-月-日-时-分-秒各字段的来源：明确给出、根据上下文推断或根据基准时间补齐
+	Fields FieldSources `json:"fields"`
+	// Interval 按时间精度对应的时间区间，如“明天”为明天0点到后天0点，“下午”为12:00-18:00
+	// 时间长度表达式为基准时间到Time
+	Interval Interval `json:"interval"`
+	// Duration 时间长度表达式按单位给出的时间长度，其他表达式为nil
+	Duration *Duration `json:"duration,omitempty"`
 }
 
 // Result 返回值
@@ -32,4 +53,7 @@ type Result struct {
 	Type ResultType `json:"type,omitempty"`
 	// Points 时间点
 	Points []ResultPoint `json:"points,omitempty"`
+	// Expressions 输入中各个独立的时间表达式，每个表达式有自己的类型、位置和时间点
+	// Type和Points为兼容旧版本保留的整体视图
+	Expressions []Expression `json:"expressions,omitempty"`
 }
@@ -0,0 +1,62 @@
+package timenlp
+
+import "time"
+
+// Schedule 重复时间的计算器，按TimeNormalizer的时区计算每次发生的时间
+// 时间按当地时间展开，夏令时切换前后的“每天早上8点”仍为当地时间8点
+type Schedule struct {
+	// Recurrence 重复时间规则
+	Recurrence
+	location *time.Location
+	holidays HolidayCalendar
+}
+
+// Schedule 基于重复时间规则新建Schedule，时区及节假日日历使用TimeNormalizer的配置
+func (n *TimeNormalizer) Schedule(rule Recurrence) *Schedule {
+	loc := n.location
+	if loc == nil {
+		loc = rule.Start.Location()
+	}
+	return &Schedule{
+		Recurrence: rule,
+		location:   loc,
+		holidays:   n.holidays,
+	}
+}
+
+// Location 计算使用的时区
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// Next 晚于after的第一次发生的时间，没有时返回零值
+func (s *Schedule) Next(after time.Time) time.Time {
+	var ret time.Time
+	s.each(after.Add(time.Nanosecond), func(t time.Time) bool {
+		ret = t
+		return false
+	})
+	return ret
+}
+
+// Between 返回[start, end)之间全部发生的时间
+func (s *Schedule) Between(start time.Time, end time.Time) []time.Time {
+	var ret []time.Time
+	s.each(start, func(t time.Time) bool {
+		if !t.Before(end) {
+			return false
+		}
+		ret = append(ret, t)
+		return true
+	})
+	return ret
+}
+
+// each 依次生成不早于after的发生时间，需要跳过节假日时跳过的日期不计入Count
+func (s *Schedule) each(after time.Time, fn func(time.Time) bool) {
+	var skip func(time.Time) bool
+	if s.ExceptHolidays && s.holidays != nil {
+		skip = s.holidays.IsHoliday
+	}
+	s.Recurrence.occurrences(after, s.location, skip, fn)
+}
@@ -8,104 +8,125 @@ import (
 	"github.com/dlclark/regexp2"
 )
 
+// numberTranslateSetting 汉字数字转换规则
 type numberTranslateSetting struct {
-	Reg  string
+	Reg  *regexp2.Regexp
 	Char string
 	Num  int64
 }
 
-// StringPreHandler 字符串预处理
-type StringPreHandler struct{}
-
-// DelKeyword 该方法删除一字符串中所有匹配某一规则字串
-// 可用于清理一个字符串中的空白符和语气助词
-// :param target: 待处理字符串
-// :param rules: 删除规则
-// :return: 清理工作完成后的字符串
-func (s StringPreHandler) DelKeyword(target string, rules string) string {
-	pattern := regexp.MustCompile(rules)
-	return pattern.ReplaceAllString(target, "")
+// numberCombineSetting 阿拉伯数字与汉字单位组合的转换规则
+type numberCombineSetting struct {
+	Reg  *regexp.Regexp
+	Char string
+	Num  int64
 }
 
-// NumberTranslator 该方法可以将字符串中所有的用汉字表示的数字转化为用阿拉伯数字表示的数字
-// 如"这里有一千两百个人，六百零五个来自中国"可以转化为
-// "这里有1200个人，605个来自中国"
-// 此外添加支持了部分不规则表达方法
-// 如两万零六百五可转化为20650
-// 两百一十四和两百十四都可以转化为214
-// 一六零加一五八可以转化为160+158
-// 该方法目前支持的正确转化范围是0-99999999
-// 该功能模块具有良好的复用性
-// :param target: 待转化的字符串
-// :return: 转化完毕后的字符串
-func (s StringPreHandler) NumberTranslator(target string) string {
-	translators := []numberTranslateSetting{
+var (
+	// numberTranslateSettings 万、千、百的汉字数字转换规则
+	numberTranslateSettings = []numberTranslateSetting{
 		{
-			Reg:  "[一二两三四五六七八九123456789]万[一二两三四五六七八九123456789](?!(千|百|十))",
+			Reg:  regexp2.MustCompile("[一二两三四五六七八九123456789]万[一二两三四五六七八九123456789](?!(千|百|十))", 0),
 			Char: "万",
 			Num:  10000,
 		},
 		{
-			Reg:  "[一二两三四五六七八九123456789]千[一二两三四五六七八九123456789](?!(百|十))",
+			Reg:  regexp2.MustCompile("[一二两三四五六七八九123456789]千[一二两三四五六七八九123456789](?!(百|十))", 0),
 			Char: "千",
 			Num:  1000,
 		},
 		{
-			Reg:  "[一二两三四五六七八九123456789]百[一二两三四五六七八九123456789](?!十)",
+			Reg:  regexp2.MustCompile("[一二两三四五六七八九123456789]百[一二两三四五六七八九123456789](?!十)", 0),
 			Char: "百",
 			Num:  100,
 		},
-		{
-			Reg: "[零一二两三四五六七八九]",
-			Num: 1,
-		},
 	}
-	for _, t := range translators {
-		target = s.translateNum(target, t)
-	}
-	target = s.translateNumExp1(target)
-	target = s.translateNumExp2(target)
-	translators = []numberTranslateSetting{
+	// numberCombineSettings 阿拉伯数字与百、千、万组合的转换规则
+	numberCombineSettings = []numberCombineSetting{
 		{
-			Reg:  "0?[1-9]百[0-9]?[0-9]?",
+			Reg:  regexp.MustCompile("0?[1-9]百[0-9]?[0-9]?"),
 			Char: "百",
 			Num:  100,
 		},
 		{
-			Reg:  "0?[1-9]千[0-9]?[0-9]?[0-9]?",
+			Reg:  regexp.MustCompile("0?[1-9]千[0-9]?[0-9]?[0-9]?"),
 			Char: "千",
 			Num:  1000,
 		},
 		{
-			Reg:  "[0-9]+万[0-9]?[0-9]?[0-9]?[0-9]?",
+			Reg:  regexp.MustCompile("[0-9]+万[0-9]?[0-9]?[0-9]?[0-9]?"),
 			Char: "万",
 			Num:  10000,
 		},
 	}
-	for _, t := range translators {
-		target = s.translateNum2(target, t)
-	}
-	return target
+	// chineseDigitPattern 单个汉字数字
+	chineseDigitPattern = regexp.MustCompile("[零一二两三四五六七八九]")
+	// weekendDayPattern 周天、周日
+	weekendDayPattern = regexp2.MustCompile("(?<=(周|星期))[天日]", 0)
+	// tenPattern 含“十”的数字
+	tenPattern = regexp2.MustCompile("(?<!(周|星期))0?[0-9]?十[0-9]?", 0)
+)
+
+// StringPreHandler 字符串预处理
+type StringPreHandler struct {
+	// matcher 限制正则匹配时间，为nil时不限制
+	matcher *matcher
 }
 
-func (s StringPreHandler) translateNum(target string, setting numberTranslateSetting) string {
-	if setting.Num == 1 {
-		pattern := regexp.MustCompile(setting.Reg)
-		if match := pattern.FindAllString(target, -1); match != nil {
-			for _, m := range match {
-				num := s.WordToNum(m)
-				target = strings.Replace(target, m, strconv.FormatInt(num, 10), 1)
-			}
-		}
-		return target
+// DelKeyword 该方法删除一字符串中所有匹配某一规则字串
+// 可用于清理一个字符串中的空白符和语气助词
+// :param target: 待处理字符串
+// :param rules: 删除规则
+// :return: 清理工作完成后的字符串
+func (s StringPreHandler) DelKeyword(target string, rules string) string {
+	pattern := regexp.MustCompile(rules)
+	return pattern.ReplaceAllString(target, "")
+}
+
+// NumberTranslator 该方法可以将字符串中所有的用汉字表示的数字转化为用阿拉伯数字表示的数字
+// 如"这里有一千两百个人，六百零五个来自中国"可以转化为
+// "这里有1200个人，605个来自中国"
+// 此外添加支持了部分不规则表达方法
+// 如两万零六百五可转化为20650
+// 两百一十四和两百十四都可以转化为214
+// 一六零加一五八可以转化为160+158
+// 该方法目前支持的正确转化范围是0-99999999
+// 该功能模块具有良好的复用性
+// :param target: 待转化的字符串
+// :return: 转化完毕后的字符串
+func (s StringPreHandler) NumberTranslator(target string) string {
+	ts := newTrackedString(target)
+	s.numberTranslate(ts)
+	return ts.String()
+}
+
+// numberTranslate 同NumberTranslator，同时记录改写前后的位置映射
+func (s StringPreHandler) numberTranslate(target *trackedString) {
+	for _, t := range numberTranslateSettings {
+		s.translateNum(target, t)
 	}
-	pattern := regexp2.MustCompile(setting.Reg, 0)
+	s.translateDigit(target)
+	s.translateNumExp1(target)
+	s.translateNumExp2(target)
+	for _, t := range numberCombineSettings {
+		s.translateNum2(target, t)
+	}
+}
+
+// translateDigit 单个汉字数字转换为阿拉伯数字
+func (s StringPreHandler) translateDigit(target *trackedString) {
+	target.replaceAllFunc(chineseDigitPattern, func(m string) string {
+		return strconv.FormatInt(s.WordToNum(m), 10)
+	})
+}
+
+func (s StringPreHandler) translateNum(target *trackedString, setting numberTranslateSetting) {
 	var match *regexp2.Match
 	for {
 		if match == nil {
-			match, _ = pattern.FindStringMatch(target)
+			match = s.matcher.find(setting.Reg, target.String())
 		} else {
-			match, _ = pattern.FindNextMatch(match)
+			match = s.matcher.findNext(setting.Reg, match)
 		}
 		if match == nil {
 			break
@@ -116,38 +137,34 @@ func (s StringPreHandler) translateNum(target string, setting numberTranslateSet
 		if len(parts) == 2 {
 			num += s.WordToNum(parts[0])*setting.Num + s.WordToNum(parts[1])*setting.Num/10
 		}
-		target = strings.Replace(target, matchedString, strconv.FormatInt(num, 10), 1)
+		target.replaceFirst(matchedString, strconv.FormatInt(num, 10))
 	}
-	return target
 }
 
-func (s StringPreHandler) translateNumExp1(target string) string {
-	pattern := regexp2.MustCompile("(?<=(周|星期))[末天日]", 0)
+func (s StringPreHandler) translateNumExp1(target *trackedString) {
 	var match *regexp2.Match
 	for {
 		if match == nil {
-			match, _ = pattern.FindStringMatch(target)
+			match = s.matcher.find(weekendDayPattern, target.String())
 		} else {
-			match, _ = pattern.FindNextMatch(match)
+			match = s.matcher.findNext(weekendDayPattern, match)
 		}
 		if match == nil {
 			break
 		}
 		matchedString := match.String()
 		num := s.WordToNum(matchedString)
-		target = strings.Replace(target, matchedString, strconv.FormatInt(num, 10), 1)
+		target.replaceFirst(matchedString, strconv.FormatInt(num, 10))
 	}
-	return target
 }
 
-func (s StringPreHandler) translateNumExp2(target string) string {
-	pattern := regexp2.MustCompile("(?<!(周|星期))0?[0-9]?十[0-9]?", 0)
+func (s StringPreHandler) translateNumExp2(target *trackedString) {
 	var match *regexp2.Match
 	for {
 		if match == nil {
-			match, _ = pattern.FindStringMatch(target)
+			match = s.matcher.find(tenPattern, target.String())
 		} else {
-			match, _ = pattern.FindNextMatch(match)
+			match = s.matcher.findNext(tenPattern, match)
 		}
 		if match == nil {
 			break
@@ -160,14 +177,12 @@ func (s StringPreHandler) translateNumExp2(target string) string {
 		}
 		unit, _ := strconv.ParseInt(parts[1], 10, 64)
 		num := ten*10 + unit
-		target = strings.Replace(target, matchedString, strconv.FormatInt(num, 10), 1)
+		target.replaceFirst(matchedString, strconv.FormatInt(num, 10))
 	}
-	return target
 }
 
-func (s StringPreHandler) translateNum2(target string, setting numberTranslateSetting) string {
-	pattern := regexp.MustCompile(setting.Reg)
-	if match := pattern.FindAllString(target, -1); match != nil {
+func (s StringPreHandler) translateNum2(target *trackedString, setting numberCombineSetting) {
+	if match := setting.Reg.FindAllString(target.String(), -1); match != nil {
 		for _, m := range match {
 			parts := s.filterStringSlice(strings.Split(m, setting.Char), "")
 			var num int64
@@ -180,10 +195,9 @@ func (s StringPreHandler) translateNum2(target string, setting numberTranslateSe
 				unit, _ := strconv.ParseInt(parts[1], 10, 64)
 				num += unit
 			}
-			target = strings.Replace(target, m, strconv.FormatInt(num, 10), 1)
+			target.replaceFirst(m, strconv.FormatInt(num, 10))
 		}
 	}
-	return target
 }
 
 // filterStringSlice 过滤数组中的字符串
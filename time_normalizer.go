@@ -1,65 +1,122 @@
 package timenlp
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 	"time"
-
-	"github.com/dlclark/regexp2"
+	"unicode/utf8"
 )
 
-// TimeNormalizer 时间表达式识别的主要工作类
+// TimeNormalizer 时间表达式识别的主要工作类，创建后只读，可以在多个goroutine之间共享，每次Parse调用的中间状态保存在独立的parseState中
 type TimeNormalizer struct {
-	isPreferFuture bool
-	isTimeSpan     bool
-	invalidSpan    bool
-	timeBase       time.Time
-	pattern        *regexp2.Regexp
-	holiSolar      map[string]string
-	holiLunar      map[string]string
+	isPreferFuture        bool
+	clock                 Clock
+	location              *time.Location
+	weekStart             time.Weekday
+	weekOfMonthConvention WeekOfMonthConvention
+	twoDigitYearPivot     int
+	dayPeriods            map[RangeTimeEnum]int
+	dayPeriodWindows      map[RangeTimeEnum]DayPeriodWindow
+	monthHeadDays         int
+	monthTailDays         int
+	fiscalYearStartMonth  time.Month
+	fillPolicy            FillPolicy
+	matchTimeout          time.Duration
+	maxInputLength        int
+	holidays              HolidayCalendar
+	holiSolar             map[string]string
+	holiLunar             map[string]string
+}
+
+// parseState 单次Parse调用的状态
+type parseState struct {
+	// ref 参考时间，所有相对时间表达式都基于该时间计算
+	ref time.Time
+	// timeBase 上下文基准时间，随识别到的时间表达式更新
+	timeBase time.Time
+	// isTimeSpan 是否识别到时间长度表达式
+	isTimeSpan bool
+	// invalidSpan 时间长度表达式是否无效
+	invalidSpan bool
+	// matcher 正则匹配入口，限制单次匹配的时间
+	matcher *matcher
+	// recurrenceSpans 重复时间规则的字符区间，其中的日期不按当月天数检查，如“每月31号”
+	recurrenceSpans [][2]int
+}
+
+// New 基于配置项新建TimeNormalizer
+func New(opts ...Option) *TimeNormalizer {
+	n := &TimeNormalizer{
+		clock:                SystemClock,
+		weekStart:            time.Sunday,
+		twoDigitYearPivot:    DefaultTwoDigitYearPivot,
+		monthHeadDays:        DefaultMonthEdgeDays,
+		monthTailDays:        DefaultMonthEdgeDays,
+		fiscalYearStartMonth: time.January,
+		matchTimeout:         DefaultMatchTimeout,
+		holidays:             StatutoryHolidays,
+		holiSolar:            holiSolar,
+		holiLunar:            holiLunar,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 // NewTimeNormalizer 新建TimeNormalizer
 // isPreferFuture: 是否倾向使用未来时间
-func NewTimeNormalizer(isPreferFuture bool) *TimeNormalizer {
-	pattern := regexp2.MustCompile(embedPattern, 0)
-	holiSolar := make(map[string]string)
-	holiLunar := make(map[string]string)
-	json.Unmarshal(embedHoliSolar, &holiSolar)
-	json.Unmarshal(embedHoliLunar, &holiLunar)
-	return &TimeNormalizer{
-		isPreferFuture: isPreferFuture,
-		pattern:        pattern,
-		holiSolar:      holiSolar,
-		holiLunar:      holiLunar,
+// opts: 其他配置项
+func NewTimeNormalizer(isPreferFuture bool, opts ...Option) *TimeNormalizer {
+	return New(append([]Option{WithPreferFuture(isPreferFuture)}, opts...)...)
+}
+
+// dayPeriodHour 时段在没有明确时间时的默认小时
+func (n *TimeNormalizer) dayPeriodHour(period RangeTimeEnum) int {
+	if hour, found := n.dayPeriods[period]; found {
+		return hour
 	}
+	return int(period)
 }
 
+// weekOffset 星期几相对每周第一天的偏移，week取值0-6，0为周日
+func (n *TimeNormalizer) weekOffset(week int) int {
+	return (week - int(n.weekStart) + 7) % 7
+}
+
+var (
+	monthDigitPattern    = regexp.MustCompile("[0-9]月[0-9]")
+	daySuffixPattern     = regexp.MustCompile("日|号")
+	monthDigitsPattern   = regexp.MustCompile("[0-9]月[0-9]+")
+	whitespacePattern    = regexp.MustCompile("\\s+")
+	modalParticlePattern = regexp.MustCompile("[的]+")
+	// decimalPointPattern 数量中的小数点，如“3点5小时”
+	decimalPointPattern = regexp.MustCompile(`\d+点\d+(?:个?(?:小时|钟头|天|周|星期|礼拜|月|年)|分钟|秒钟)`)
+)
+
 // filter 这里对一些不规范的表达做转换
-func (n *TimeNormalizer) filter(inputQuery string) string {
-	preHandler := &StringPreHandler{}
-	inputQuery = preHandler.NumberTranslator(inputQuery)
+func (n *TimeNormalizer) filter(inputQuery *trackedString, m *matcher) {
+	preHandler := &StringPreHandler{matcher: m}
+	preHandler.numberTranslate(inputQuery)
 	{
-		pattern := regexp.MustCompile("[0-9]月[0-9]")
-		if pattern.MatchString(inputQuery) {
-			index := strings.Index(inputQuery, "月")
-			pattern := regexp.MustCompile("日|号")
-			if !pattern.MatchString(inputQuery[index:]) {
-				pattern := regexp.MustCompile("[0-9]月[0-9]+")
-				if loc := pattern.FindStringIndex(inputQuery); loc != nil {
-					inputQuery = fmt.Sprintf("%s号%s", inputQuery[0:loc[1]], inputQuery[loc[1]:])
+		if query := inputQuery.String(); monthDigitPattern.MatchString(query) {
+			index := strings.Index(query, "月")
+			if !daySuffixPattern.MatchString(query[index:]) {
+				if loc := monthDigitsPattern.FindStringIndex(query); loc != nil {
+					inputQuery.replace(loc[1], loc[1], "号")
 				}
 			}
 		}
 	}
-	if !strings.Contains(inputQuery, "月") && !strings.Contains(inputQuery, "个半") {
-		inputQuery = strings.ReplaceAll(inputQuery, "个", "")
+	// “3点5小时”中的“点”为小数点，“3点5”仍为时间
+	inputQuery.replaceAllFunc(decimalPointPattern, func(m string) string {
+		return strings.Replace(m, "点", ".", 1)
+	})
+	if query := inputQuery.String(); !strings.Contains(query, "月") && !strings.Contains(query, "个半") && !weekdayOrdinalPattern.MatchString(query) {
+		inputQuery.replaceAll("个", "")
 	}
 	replaces := [][]string{
-		{"中旬", "15号"},
 		{"傍晚", "午后"},
 		{"大年", ""},
 		{"五一", "劳动节"},
@@ -67,53 +124,97 @@ func (n *TimeNormalizer) filter(inputQuery string) string {
 		{"：", ":"},
 	}
 	for _, rpl := range replaces {
-		inputQuery = strings.ReplaceAll(inputQuery, rpl[0], rpl[1])
+		inputQuery.replaceAll(rpl[0], rpl[1])
 	}
-	return inputQuery
 }
 
 // preHandling 待匹配字符串的清理空白符和语气助词以及大写数字转化的预处理
-func (n *TimeNormalizer) preHandling(target string) string {
-	preHandler := &StringPreHandler{}
-	rules := []string{
-		"\\s+",
-		"[的]+",
-	}
-	for _, rule := range rules {
-		target = preHandler.DelKeyword(target, rule)
+func (n *TimeNormalizer) preHandling(target *trackedString, m *matcher) {
+	preHandler := &StringPreHandler{matcher: m}
+	for _, pattern := range []*regexp.Regexp{whitespacePattern, modalParticlePattern} {
+		target.replaceAllFunc(pattern, func(string) string { return "" })
 	}
-	target = preHandler.NumberTranslator(target)
-	return target
+	preHandler.numberTranslate(target)
+}
+
+// ParseNow 以时钟的当前时间为基准时间进行时间表达式提取
+func (n *TimeNormalizer) ParseNow(target string) (*Result, error) {
+	return n.Parse(target, n.clock.Now())
 }
 
 // Parse 是TimeNormalizer的构造方法，根据提供的待分析字符串和timeBase进行时间表达式提取
+// 所有相对时间表达式（如“3天前”、“周一”）都以timeBase为参考时间，timeBase为零值时使用时钟的当前时间
 func (n *TimeNormalizer) Parse(target string, timeBase time.Time) (*Result, error) {
-	n.timeBase = timeBase
-	target = n.filter(target)
-	target = n.preHandling(target)
-	timeUnits := n.timeExt(target, timeBase)
+	return n.ParseContext(context.Background(), target, timeBase)
+}
+
+// ParseContext 同Parse，支持通过ctx取消或设置截止时间，截止时间同时作为单次正则匹配超时时间的上限
+// 返回的错误均可通过errors.Is/errors.As判断：
+// 没有识别到时间表达式返回ErrNoMatch，识别到的时间均无效或重复时间规则的间隔、次数超出范围时返回RangeError或InvalidDateError，
+// 输入超过最大长度时返回InputTooLongError，正则匹配超时返回ErrMatchTimeout，ctx结束时返回CanceledError
+func (n *TimeNormalizer) ParseContext(ctx context.Context, target string, timeBase time.Time) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &CanceledError{Err: err}
+	}
+	if n.maxInputLength > 0 {
+		if l := utf8.RuneCountInString(target); l > n.maxInputLength {
+			return nil, &InputTooLongError{Length: l, Max: n.maxInputLength}
+		}
+	}
+	if timeBase.IsZero() {
+		timeBase = n.clock.Now()
+	}
+	if n.location != nil {
+		timeBase = timeBase.In(n.location)
+	}
+	state := &parseState{
+		ref:      timeBase,
+		timeBase: timeBase,
+		matcher:  newMatcher(ctx, n.matchTimeout),
+	}
+	text := newTrackedString(target)
+	n.filter(text, state.matcher)
+	n.preHandling(text, state.matcher)
+	if err := state.matcher.matchErr(); err != nil {
+		return nil, err
+	}
+	recurrences := n.recurrences(text.String())
+	var skips [][2]int
+	for _, m := range recurrences {
+		if m.err != nil {
+			return nil, m.err
+		}
+		skips = append(skips, m.skips...)
+		state.recurrenceSpans = append(state.recurrenceSpans, [2]int{m.start, m.end})
+	}
+	timeUnits, err := n.timeExt(ctx, text, state, skips)
+	if err != nil {
+		return nil, err
+	}
 	ret := Result{
-		NormalizedString: target,
+		NormalizedString: text.String(),
+		Expressions:      n.expressions(text, timeUnits, recurrences, state.ref),
+	}
+	for _, e := range ret.Expressions {
+		ret.Points = append(ret.Points, e.Points...)
 	}
-	if len(timeUnits) == 0 {
-		return nil, errors.New("no time pattern could be extracted")
-	} else if n.isTimeSpan && !n.invalidSpan {
+	if len(ret.Points) == 0 {
+		return nil, ErrNoMatch
+	} else if state.isTimeSpan && !state.invalidSpan {
 		ret.Type = DELTA
-	} else if len(timeUnits) == 1 {
+	} else if len(ret.Points) == 1 {
 		ret.Type = TIMESTAMP
 	} else {
 		ret.Type = SPAN
 	}
-	for _, v := range timeUnits {
-		ret.Points = append(ret.Points, v.ToResultPoint())
-	}
 	return &ret, nil
 }
 
 // timeExt 有基准时间输入的时间表达式识别
 // 这是时间表达式识别的主方法， 通过已经构建的正则表达式对字符串进行识别，并按照预先定义的基准时间进行规范化
 // 将所有别识别并进行规范化的时间表达式进行返回， 时间表达式通过TimeUnit类进行定义
-func (n *TimeNormalizer) timeExt(target string, timeBase time.Time) []TimeUnit {
+// skips中的字符区间不参与识别，如重复时间规则中的间隔“每隔2天”
+func (n *TimeNormalizer) timeExt(ctx context.Context, text *trackedString, state *parseState, skips [][2]int) ([]TimeUnit, error) {
 	var (
 		startLine = -1
 		endLine   = -1
@@ -122,36 +223,73 @@ func (n *TimeNormalizer) timeExt(target string, timeBase time.Time) []TimeUnit {
 		pos       []int
 		length    []int
 	)
-	m, _ := n.pattern.FindStringMatch(target)
+	m := state.matcher.find(timePattern, text.String())
 	for m != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, &CanceledError{Err: err}
+		}
+		if skipped(skips, m.Index, m.Index+m.Length) {
+			m = state.matcher.findNext(timePattern, m)
+			continue
+		}
 		startLine = m.Index
 		if startLine == endLine { // 假如下一个识别到的时间字段和上一个是相连的 @author kexm
 			rPointer -= 1
 			temp[rPointer] = temp[rPointer] + m.String() // 则把下一个识别到的时间字段加到上一个时间字段去
+			length[rPointer] += m.Length
 		} else {
 			temp = append(temp, m.String())
+			pos = append(pos, m.Index)
+			length = append(length, m.Length)
 		}
-		pos = append(pos, m.Index)
-		length = append(length, m.Length)
 		endLine = m.Index + m.Length
 		rPointer += 1
-		m, _ = n.pattern.FindNextMatch(m)
+		m = state.matcher.findNext(timePattern, m)
 	}
-	var res []TimeUnit
+	if err := state.matcher.matchErr(); err != nil {
+		return nil, err
+	}
+	var (
+		res      []TimeUnit
+		firstErr error
+	)
 	// 时间上下文： 前一个识别出来的时间会是下一个时间的上下文，用于处理：周六3点到5点这样的多个时间的识别，第二个5点应识别到是周六的。
 	tpCtx := DefaultTimePoint
 	idx := 0
 	for idx < rPointer {
-		unit := NewTimeUnit(temp[idx], pos[idx], length[idx], n, tpCtx)
+		if err := ctx.Err(); err != nil {
+			return nil, &CanceledError{Err: err}
+		}
+		unit := newTimeUnit(temp[idx], pos[idx], length[idx], n, state, tpCtx)
+		unit.span = text.span(pos[idx], pos[idx]+length[idx])
 		idx += 1
-		if unit.ts.IsZero() {
+		if err := state.matcher.matchErr(); err != nil {
+			return nil, err
+		}
+		if unit.err != nil && firstErr == nil {
+			firstErr = unit.err
+		}
+		if unit.err != nil || unit.ts.IsZero() {
 			continue
 		}
 		res = append(res, *unit)
 		tpCtx = unit.tp
 	}
 	res = n.filterTimeUnits(res)
-	return res
+	if len(res) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return res, nil
+}
+
+// skipped 字符区间[start, end)是否在skips中
+func skipped(skips [][2]int, start int, end int) bool {
+	for _, v := range skips {
+		if start >= v[0] && end <= v[1] {
+			return true
+		}
+	}
+	return false
 }
 
 // filterTimeUnits 过滤空时间点
@@ -1,6 +1,8 @@
 package timenlp
 
 import (
+	"context"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,22 +15,48 @@ import (
 type TimeUnit struct {
 	expTime                 string
 	normalizer              *TimeNormalizer
+	state                   *parseState
 	tp                      TimePoint
 	tpOrigin                TimePoint
 	noYear                  bool
 	isMorning               bool
 	isAllDayTime            bool
 	isFirstTimeSolveContext bool
+	isTimeSpan              bool // 是否为时间长度表达式
+	invalidSpan             bool // 时间长度表达式是否无效
 	pos                     int
 	length                  int
+	span                    Span
+	granularity             Granularity   // 时间精度
+	sources                 FieldSources  // 各字段来源
+	period                  RangeTimeEnum // 没有明确小时时使用的时段
+	months                  int           // 按月计算的区间包含的月数，如“上半年”为6
+	days                    int           // 按天计算的区间包含的天数，如“下周”为7
+	window                  time.Duration // 时辰、更、刻对应的时间窗口长度，如“子时”为2小时
+	interval                Interval      // 表达式对应的时间区间
+	weeks                   int           // 时间长度中按周给出的部分
+	duration                Duration      // 时间长度
 	ts                      time.Time
+	err                     error
 }
 
-// NewTimeUnit 新建TimeUnit
+// NewTimeUnit 新建TimeUnit，以normalizer时钟的当前时间为基准时间
 func NewTimeUnit(expTime string, pos int, length int, normalizer *TimeNormalizer, tpCtx TimePoint) *TimeUnit {
+	now := normalizer.clock.Now()
+	state := &parseState{
+		ref:      now,
+		timeBase: now,
+		matcher:  newMatcher(context.Background(), normalizer.matchTimeout),
+	}
+	return newTimeUnit(expTime, pos, length, normalizer, state, tpCtx)
+}
+
+// newTimeUnit 基于单次Parse调用的状态新建TimeUnit
+func newTimeUnit(expTime string, pos int, length int, normalizer *TimeNormalizer, state *parseState, tpCtx TimePoint) *TimeUnit {
 	ret := &TimeUnit{
 		expTime:                 expTime,
 		normalizer:              normalizer,
+		state:                   state,
 		tp:                      DefaultTimePoint,
 		tpOrigin:                tpCtx,
 		isMorning:               false,
@@ -38,16 +66,34 @@ func NewTimeUnit(expTime string, pos int, length int, normalizer *TimeNormalizer
 		length:                  length,
 	}
 	ret.normalization()
+	if ret.isTimeSpan {
+		state.isTimeSpan = true
+	} else if ret.invalidSpan {
+		state.isTimeSpan = false
+		state.invalidSpan = true
+	}
 	return ret
 }
 
 // ToResultPoint 转换为ResultPoint
 func (t TimeUnit) ToResultPoint() ResultPoint {
-	return ResultPoint{
-		Time:   t.Time(),
-		Pos:    t.pos,
-		Length: t.length,
+	ret := ResultPoint{
+		Time:        t.Time(),
+		Span:        t.span,
+		Granularity: t.granularity,
+		Fields:      t.sources,
+		Interval:    t.interval,
+	}
+	if t.isTimeSpan {
+		duration := t.duration
+		ret.Duration = &duration
 	}
+	return ret
+}
+
+// hasDate 年、月、日是否有明确给出的字段
+func (t TimeUnit) hasDate() bool {
+	return t.sources[0] == FIELD_EXPLICIT || t.sources[1] == FIELD_EXPLICIT || t.sources[2] == FIELD_EXPLICIT
 }
 
 // Time 转换为time.Time
@@ -63,13 +109,29 @@ func (t *TimeUnit) normalization() {
 	t.normSetMonthFuzzyDay()
 	t.normSetBaseRelated()
 	t.normSetCurRelated()
+	t.normSetYearPart()
+	t.normSetQuarter()
+	t.normSetMonthPart()
+	t.normSetWeekRange()
+	t.normSetWeekOrdinal()
+	t.normSetPeriodOrdinal()
 	t.normSetHour()
 	t.normSetMinute()
+	t.normSetDoubleHour()
 	t.normSetSecond()
 	t.normSetSpecial()
 	t.normSetSpanRelated()
 	t.normSetHoliday()
 	t.normSetTotal()
+	if t.err != nil {
+		return
+	}
+	if !t.isTimeSpan && (t.tp[0] != -1 || t.tp[1] != -1 || t.tp[2] != -1 || t.tp[3] != -1) {
+		t.normSetImpliedMonth()
+		if t.err = t.validate(); t.err != nil {
+			return
+		}
+	}
 	t.modifyTimeBase()
 	for idx, v := range t.tp {
 		t.tpOrigin[idx] = v
@@ -86,9 +148,9 @@ func (t *TimeUnit) normalization() {
 		idx += 1
 	}
 	if flag {
-		t.normalizer.isTimeSpan = true
+		t.isTimeSpan = true
 	}
-	if t.normalizer.isTimeSpan {
+	if t.isTimeSpan {
 		t.normalizeTimeSpan()
 		return
 	}
@@ -96,50 +158,223 @@ func (t *TimeUnit) normalization() {
 	for tunitPointer >= 0 && t.tp[tunitPointer] < 0 {
 		tunitPointer -= 1
 	}
+	if tunitPointer >= 0 {
+		t.granularity = timePointGranularities[tunitPointer]
+	}
+	for idx, v := range t.tp {
+		if v != -1 && t.sources[idx] == FIELD_UNSET {
+			t.sources[idx] = FIELD_EXPLICIT
+		}
+	}
 	idx = 0
-	timeGrid := NewTimePointFromTime(t.normalizer.timeBase)
+	timeGrid := NewTimePointFromTime(t.state.timeBase)
 	for idx < tunitPointer {
 		if t.tp[idx] < 0 {
 			t.tp[idx] = timeGrid[idx]
+			t.sources[idx] = FIELD_BASE
 		}
 		idx += 1
 	}
+	if t.normalizer.fillPolicy == FillBase {
+		for idx = tunitPointer + 1; idx < len(t.tp); idx++ {
+			t.tp[idx] = timeGrid[idx]
+			t.sources[idx] = FIELD_BASE
+		}
+	}
 	t.ts = t.genTime()
+	t.checkYear(t.ts)
+	if !t.ts.IsZero() {
+		t.interval = t.genInterval(tunitPointer)
+	}
 }
 
-func (t *TimeUnit) normalizeTimeSpan() {
-	var days int64
-	if t.tp[0] > 0 {
-		days += int64(365 * t.tp[0])
+// maxYear 支持的最大年份
+const maxYear = 9999
+
+// maxSpanDays 支持的最长时间长度（天）
+const maxSpanDays = maxYear * 366
+
+// atoi 转换数字，数值溢出时记录字段范围为[min, max]的RangeError并返回0
+func (t *TimeUnit) atoi(field string, str string, min int, max int) int {
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		t.setErr(&RangeError{Field: field, Value: str, Min: min, Max: max})
+		return 0
+	}
+	return v
+}
+
+// setErr 记录第一个错误
+func (t *TimeUnit) setErr(err error) {
+	if t.err == nil {
+		t.err = err
 	}
-	if t.tp[1] > 0 {
-		days += int64(30 * t.tp[1])
+}
+
+// find 在text中查找pattern的第一个匹配，匹配超时由Parse统一返回
+func (t *TimeUnit) find(pattern *regexp2.Regexp, text string) *regexp2.Match {
+	return t.state.matcher.find(pattern, text)
+}
+
+// match text中是否存在pattern的匹配
+func (t *TimeUnit) match(pattern *regexp2.Regexp, text string) bool {
+	return t.state.matcher.match(pattern, text)
+}
+
+// checkYear 检查年份是否在支持范围内
+func (t *TimeUnit) checkYear(ts time.Time) {
+	if year := ts.Year(); year < 1 || year > maxYear {
+		t.setErr(&RangeError{Field: "year", Value: strconv.Itoa(year), Min: 1, Max: maxYear})
+		t.ts = time.Time{}
 	}
-	if t.tp[2] > 0 {
-		days += int64(t.tp[2])
+}
+
+// validate 检查识别出的月、日、时、分、秒是否有效
+func (t *TimeUnit) validate() error {
+	tp := t.tp
+	invalid := (tp[1] != -1 && (tp[1] < 1 || tp[1] > 12)) ||
+		(tp[3] != -1 && (tp[3] < 0 || tp[3] > 24)) ||
+		(tp[4] != -1 && (tp[4] < 0 || tp[4] > 59)) ||
+		(tp[5] != -1 && (tp[5] < 0 || tp[5] > 59))
+	if !invalid && tp[2] != -1 && t.inRecurrence() {
+		// 重复时间规则中的日期只需在某一年的该月存在，如“每年2月29号”
+		days := 31
+		if tp[1] != -1 && t.sources[1] != FIELD_BASE {
+			days = daysIn(2000, time.Month(tp[1]))
+		}
+		invalid = tp[2] < 1 || tp[2] > days
+	} else if !invalid && tp[2] != -1 {
+		base := NewTimePointFromTime(t.state.timeBase)
+		year, month := tp[0], tp[1]
+		if year == -1 {
+			year = base[0]
+		}
+		if month == -1 {
+			month = base[1]
+		}
+		invalid = tp[2] < 1 || tp[2] > daysIn(year, time.Month(month))
 	}
-	var tunit TimePoint
-	idx := 3
-	for idx < 6 {
-		if t.tp[idx] < 0 {
-			tunit[idx] = 0
-		} else {
-			tunit[idx] = t.tp[idx]
+	if invalid {
+		return &InvalidDateError{Expression: t.expTime, Point: tp}
+	}
+	return nil
+}
+
+// normSetImpliedMonth 没有指明月份时，当月没有该日的取之后第一个有该日的月份，如9月20日说“31号”为10月31日
+func (t *TimeUnit) normSetImpliedMonth() {
+	day := t.tp[2]
+	if day < 1 || day > 31 || (t.tp[1] != -1 && t.sources[1] != FIELD_BASE) {
+		return
+	}
+	base := NewTimePointFromTime(t.state.timeBase)
+	year, month := t.tp[0], t.tp[1]
+	if year == -1 {
+		year = base[0]
+	}
+	if month == -1 {
+		month = base[1]
+	}
+	// 12月有31天，不会跨年
+	for day > daysIn(year, time.Month(month)) {
+		month++
+	}
+	if month == t.tp[1] || (t.tp[1] == -1 && month == base[1]) {
+		return
+	}
+	if t.tp[0] == -1 {
+		t.tp[0], t.sources[0] = year, FIELD_BASE
+	}
+	t.tp[1], t.sources[1] = month, FIELD_BASE
+}
+
+// inRecurrence 是否属于重复时间规则，如“每月31号”中的“31号”
+func (t *TimeUnit) inRecurrence() bool {
+	for _, v := range t.state.recurrenceSpans {
+		if t.pos >= v[0] && t.pos < v[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// daysIn 某年某月的天数
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// normalizeTimeSpan 时间长度表达式，结束时间按日历计算
+func (t *TimeUnit) normalizeTimeSpan() {
+	var d Duration
+	for idx, v := range t.tp {
+		if v < 0 {
+			continue
+		}
+		switch idx {
+		case 0:
+			d.Years = v
+		case 1:
+			d.Months = v
+		case 2:
+			d.Weeks = t.weeks
+			d.Days = v - t.weeks*7
+		case 3:
+			d.Hours = v
+		case 4:
+			d.Minutes = v
+		case 5:
+			d.Seconds = v
 		}
-		idx++
 	}
-	seconds := int64(tunit[3]*3600) + int64(tunit[4]*60) + int64(tunit[5])
-	if seconds == 0 && days == 0 {
-		t.normalizer.isTimeSpan = false
-		t.normalizer.invalidSpan = true
+	if d.IsZero() {
+		t.isTimeSpan = false
+		t.invalidSpan = true
+		return
+	}
+	days := 365*int64(d.Years) + 30*int64(d.Months) + 7*int64(d.Weeks) + int64(d.Days)
+	seconds := d.clockSeconds()
+	if days > maxSpanDays || seconds > maxSpanDays*86400 {
+		t.setErr(&RangeError{Field: "duration days", Value: strconv.FormatInt(days+seconds/86400, 10), Min: 0, Max: maxSpanDays})
 		return
 	}
-	t.ts = t.normalizer.timeBase.Add(t.genSpan(days, seconds)).Truncate(time.Second)
+	t.duration = d
+	t.ts = d.AddTo(t.state.timeBase).Truncate(time.Second)
+	t.checkYear(t.ts)
+	if !t.ts.IsZero() {
+		t.interval = Interval{Start: t.state.timeBase, End: t.ts}
+	}
 }
 
-// genSpan 转化为time.Duration
-func (t *TimeUnit) genSpan(days int64, second int64) time.Duration {
-	return (time.Duration(days)*24*time.Hour + time.Duration(second)*time.Second)
+// genInterval 根据时间精度生成区间，时段表达式使用时段对应的时间窗口
+func (t *TimeUnit) genInterval(tunitPointer int) Interval {
+	if tunitPointer < 0 {
+		return Interval{}
+	}
+	loc := t.state.timeBase.Location()
+	tp := t.tp
+	for idx := tunitPointer + 1; idx < len(tp); idx++ {
+		if idx == 1 || idx == 2 {
+			tp[idx] = 1
+		} else {
+			tp[idx] = 0
+		}
+	}
+	start := tp.ToTime(loc)
+	if t.months > 0 && tunitPointer == 1 {
+		return Interval{Start: start, End: start.AddDate(0, t.months, 0)}
+	}
+	if t.days > 0 && tunitPointer == 2 {
+		return Interval{Start: start, End: start.AddDate(0, 0, t.days)}
+	}
+	if t.window > 0 && tunitPointer >= 3 {
+		return Interval{Start: start, End: start.Add(t.window)}
+	}
+	if t.period != 0 && tunitPointer == 3 {
+		if w, found := t.normalizer.dayPeriodWindow(t.period); found {
+			day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+			return Interval{Start: day.Add(w.Start), End: day.Add(w.End)}
+		}
+	}
+	return Interval{Start: start, End: t.addTime(start, tunitPointer)}
 }
 
 // getTime 获取time.Time
@@ -151,97 +386,88 @@ func (t *TimeUnit) genTime() time.Time {
 			ret[idx] = v
 		}
 	}
-	return ret.ToTime(t.normalizer.timeBase.Location())
+	return ret.ToTime(t.state.timeBase.Location())
 }
 
+var (
+	yearOneDigitPattern   = regexp2.MustCompile("(?<![0-9])[0-9]{1}(?=年)", 0)
+	yearTwoDigitPattern   = regexp2.MustCompile("[0-9]{2}(?=年)", 0)
+	yearThreeDigitPattern = regexp2.MustCompile("(?<![0-9])[0-9]{3}(?=年)", 0)
+	yearFourDigitPattern  = regexp2.MustCompile("[0-9]{4}(?=年)", 0)
+)
+
 // normSetYear 年-规范化方法--该方法识别时间表达式单元的年字段
 func (t *TimeUnit) normSetYear() {
+	// “2年以后”等时间偏移在normSetBaseRelated中处理，“1.5年”等在normSetSpanRelated中处理
+	expTime := withoutQuantities(t.expTime)
 	// 一位数表示的年份
 	{
-		pattern := regexp2.MustCompile("(?<![0-9])[0-9]{1}(?=年)", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			t.normalizer.isTimeSpan = true
-			year, _ := strconv.Atoi(match.String())
+		if match := t.find(yearOneDigitPattern, expTime); match != nil {
+			t.isTimeSpan = true
+			year := t.atoi("year", match.String(), 0, maxYear)
 			t.tp[0] = year
 		}
 	}
 	// 两位数表示的年份
 	{
-		pattern := regexp2.MustCompile("[0-9]{2}(?=年)", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			year, _ := strconv.Atoi(match.String())
+		if match := t.find(yearTwoDigitPattern, expTime); match != nil {
+			year := t.atoi("year", match.String(), 0, maxYear)
 			t.tp[0] = year
 		}
 	}
 	// 三位数表示的年份
 	{
-		pattern := regexp2.MustCompile("(?<![0-9])[0-9]{3}(?=年)", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			t.normalizer.isTimeSpan = true
-			year, _ := strconv.Atoi(match.String())
+		if match := t.find(yearThreeDigitPattern, expTime); match != nil {
+			t.isTimeSpan = true
+			year := t.atoi("year", match.String(), 0, maxYear)
 			t.tp[0] = year
 		}
 	}
 	// 四位数表示的年份
 	{
-		pattern := regexp2.MustCompile("[0-9]{4}(?=年)", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			year, _ := strconv.Atoi(match.String())
+		if match := t.find(yearFourDigitPattern, expTime); match != nil {
+			year := t.atoi("year", match.String(), 0, maxYear)
 			t.tp[0] = year
 		}
 	}
 }
 
+var monthPattern = regexp2.MustCompile("((10)|(11)|(12)|([1-9]))(?=月)", 0)
+
 // normSetMonth 月-规范化方法--该方法识别时间表达式单元的月字段
 func (t *TimeUnit) normSetMonth() {
-	pattern := regexp2.MustCompile("((10)|(11)|(12)|([1-9]))(?=月)", 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-		month, _ := strconv.Atoi(match.String())
+	if match := t.find(monthPattern, t.expTime); match != nil {
+		month := t.atoi("month", match.String(), 1, 12)
 		t.tp[1] = month
 		t.preferFuture(1)
 	}
-	pattern2 := regexp.MustCompile(`第(\d+)季`)
-	if match := pattern2.FindAllStringSubmatch(t.expTime, 1); len(match) > 0 && len(match[0]) == 2 {
-		if season, _ := strconv.Atoi(match[0][1]); season > 0 {
-			cur := t.tp.ToTime(t.normalizer.timeBase.Location())
-			cur = cur.AddDate(0, (season-1)*4, 0)
-			t.tp[0] = cur.Year()
-			t.tp[1] = int(cur.Month())
-			t.preferFuture(1)
-		}
-	}
 }
 
+var dayPattern = regexp2.MustCompile("((?<!\\d))([0-3][0-9]|[1-9])(?=(日|号))", 0)
+
 // normSetDay 日-规范化方法：该方法识别时间表达式单元的日字段
 func (t *TimeUnit) normSetDay() {
-	pattern := regexp2.MustCompile("((?<!\\d))([0-3][0-9]|[1-9])(?=(日|号))", 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-		day, _ := strconv.Atoi(match.String())
+	if match := t.find(dayPattern, t.expTime); match != nil {
+		day := t.atoi("day", match.String(), 1, 31)
 		t.tp[2] = day
 		t.preferFuture(2)
 		t.checkTime(t.tp)
 	}
-	pattern2 := regexp.MustCompile(`第(\d+)周`)
-	if match := pattern2.FindAllStringSubmatch(t.expTime, 1); len(match) > 0 && len(match[0]) == 2 {
-		if weeks, _ := strconv.Atoi(match[0][1]); weeks > 0 {
-			cur := t.tp.ToTime(t.normalizer.timeBase.Location())
-			cur = cur.AddDate(0, 0, (weeks-1)*7)
-			t.tp[0] = cur.Year()
-			t.tp[1] = int(cur.Month())
-			t.tp[2] = cur.Day()
-		}
-	}
 }
 
+var (
+	monthFuzzyDayPattern = regexp.MustCompile(`((10)|(11)|(12)|([1-9]))(月|\.|\-)([0-3][0-9]|[1-9])`)
+	monthDaySepPattern   = regexp.MustCompile(`(月|\.|\-)`)
+)
+
 // normSetMonthFuzzyDay 月-日 兼容模糊写法：该方法识别时间表达式单元的月、日字段
 func (t *TimeUnit) normSetMonthFuzzyDay() {
-	pattern := regexp.MustCompile(`((10)|(11)|(12)|([1-9]))(月|\.|\-)([0-3][0-9]|[1-9])`)
-	match := pattern.FindAllString(t.expTime, -1)
+	// “1.5小时”中的小数不是月.日
+	match := monthFuzzyDayPattern.FindAllString(withoutQuantities(t.expTime), -1)
 	for _, m := range match {
-		p := regexp.MustCompile(`(月|\.|\-)`)
-		if loc := p.FindStringIndex(m); loc != nil {
-			month, _ := strconv.Atoi(m[0:loc[0]])
-			day, _ := strconv.Atoi(m[loc[1]:])
+		if loc := monthDaySepPattern.FindStringIndex(m); loc != nil {
+			month := t.atoi("month", m[0:loc[0]], 1, 12)
+			day := t.atoi("day", m[loc[1]:], 1, 31)
 			t.tp[1] = month
 			t.tp[2] = day
 			// 处理倾向于未来时间的情况
@@ -251,71 +477,188 @@ func (t *TimeUnit) normSetMonthFuzzyDay() {
 	}
 }
 
-// normSetBaseRelated 设置以上文时间为基准的时间偏移计算
-func (t *TimeUnit) normSetBaseRelated() {
-	cur := time.Now()
-	flag := []bool{false, false, false, false}
-	settings := []struct {
-		Reg     string
-		FlagIdx int
-		Negtive bool
-	}{
-		{Reg: "\\d+(?=(个)?小时[以之]?前)", FlagIdx: 3, Negtive: true},
-		{Reg: "\\d+(?=(个)?小时[以之]?后)", FlagIdx: 3},
-		{Reg: "\\d+(?=天[以之]?前)", FlagIdx: 2, Negtive: true},
-		{Reg: "\\d+(?=天[以之]?后)", FlagIdx: 2},
-		{Reg: "\\d+(?=(个)?月[以之]?前)", FlagIdx: 1, Negtive: true},
-		{Reg: "\\d+(?=(个)?月[以之]?后)", FlagIdx: 1},
-		{Reg: "\\d+(?=年[以之]?前)", FlagIdx: 0, Negtive: true},
-		{Reg: "\\d+(?=年[以之]?后)", FlagIdx: 0},
-	}
-	var updateFlag bool
-	for _, s := range settings {
-		cur, updateFlag = t.calcNormSetBaseRelated(cur, s.Reg, s.FlagIdx, s.Negtive)
-		if updateFlag {
-			flag[s.FlagIdx] = true
+var (
+	// baseRelatedPattern 以基准时间为参照的时间偏移，如“3天后”、“1小时30分钟以前”、“半小时之后”、“两天半以后”
+	baseRelatedPattern = regexp.MustCompile(`((?:(?:\d+(?:\.\d+)?)?(?:个半|个)?(?:大半|半)?个?(?:秒钟?|分钟|刻钟|小时|钟头|天|周|星期|礼拜|月|年)半?)+)[以之]?([前后])`)
+	// quantityPattern 数量和单位，数量可以为小数或带“半”，如“1个半小时”、“2天半”、“半个月”、“1.5小时”、“大半天”
+	quantityPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)?(个半|个)?(大半|半)?个?(秒钟?|分钟|刻钟|小时|钟头|天|周|星期|礼拜|月|年)(半)?`)
+)
+
+// quantityUnits 数量单位对应的字段及倍数，周按7天计算，刻钟按15分钟计算
+var quantityUnits = map[string]struct {
+	Idx   int
+	Scale int
+}{
+	"年":  {Idx: 0, Scale: 1},
+	"月":  {Idx: 1, Scale: 1},
+	"周":  {Idx: 2, Scale: 7},
+	"星期": {Idx: 2, Scale: 7},
+	"礼拜": {Idx: 2, Scale: 7},
+	"天":  {Idx: 2, Scale: 1},
+	"小时": {Idx: 3, Scale: 1},
+	"钟头": {Idx: 3, Scale: 1},
+	"刻钟": {Idx: 4, Scale: 15},
+	"分钟": {Idx: 4, Scale: 1},
+	"秒":  {Idx: 5, Scale: 1},
+	"秒钟": {Idx: 5, Scale: 1},
+}
+
+// quantityCarries 小数部分换算为下一级单位的倍数，月按30天计算，如“半个月”为15天
+var quantityCarries = [5]float64{12, 30, 24, 60, 60}
+
+// maxQuantity 支持的最大数量
+const maxQuantity = 1e9
+
+// quantity 文本中的一个数量
+type quantity struct {
+	// text 原始文字
+	text string
+	// value 数量
+	value float64
+	// unit 单位
+	unit string
+	// fractional 是否为小数或带“半”
+	fractional bool
+}
+
+// findQuantities 识别文本中的数量，“上半年”、“下半年”等中的“半”不作为数量
+func findQuantities(str string) []quantity {
+	var ret []quantity
+	for _, loc := range quantityPattern.FindAllStringSubmatchIndex(str, -1) {
+		sub := func(idx int) string {
+			if loc[idx*2] < 0 {
+				return ""
+			}
+			return str[loc[idx*2]:loc[idx*2+1]]
+		}
+		number, half := sub(1), sub(3)
+		if number == "" && half == "" {
+			continue
+		}
+		if number == "" && (strings.HasSuffix(str[:loc[0]], "上") || strings.HasSuffix(str[:loc[0]], "下") || strings.HasSuffix(str[:loc[0]], "前") || strings.HasSuffix(str[:loc[0]], "后")) {
+			continue
+		}
+		q := quantity{text: sub(0), unit: sub(4)}
+		q.value, _ = strconv.ParseFloat(number, 64)
+		q.fractional = strings.Contains(number, ".") || half != ""
+		if sub(2) == "个半" || sub(5) == "半" {
+			q.value += 0.5
+			q.fractional = true
 		}
+		switch half {
+		case "半":
+			q.value += 0.5
+		case "大半":
+			q.value += 0.75
+		}
+		ret = append(ret, q)
 	}
-	if flag[0] || flag[1] || flag[2] || flag[3] {
-		t.tp[0] = cur.Year()
+	return ret
+}
+
+// fields 将数量换算为年、月、日、时、分、秒，小数部分按下一级单位计算，如“2天半”为2天12小时
+// weeks为其中整周的数量，已计入日
+func (q quantity) fields() (fields [6]int, weeks int) {
+	unit := quantityUnits[q.unit]
+	value := q.value
+	idx := unit.Idx
+	if unit.Idx == 2 && unit.Scale == 7 {
+		weeks = int(math.Floor(value))
+		value = (value - float64(weeks)) * 7
+		fields[2] = weeks * 7
+	} else {
+		value *= float64(unit.Scale)
 	}
-	if flag[1] || flag[2] || flag[3] {
-		t.tp[1] = int(cur.Month())
+	for ; idx < len(quantityCarries); idx++ {
+		whole := math.Floor(value + 1e-9)
+		fields[idx] += int(whole)
+		if value -= whole; value < 1e-9 {
+			return
+		}
+		value *= quantityCarries[idx]
 	}
-	if flag[2] || flag[3] {
-		t.tp[2] = cur.Day()
+	fields[5] += int(math.Round(value))
+	return
+}
+
+// baseRelatedMatch 时间偏移表达式，四位数的年份如“2000年以前”不作为时间偏移
+func baseRelatedMatch(expTime string) []string {
+	match := baseRelatedPattern.FindStringSubmatchIndex(expTime)
+	if match == nil {
+		return nil
+	}
+	for _, q := range findQuantities(expTime[match[2]:match[3]]) {
+		if q.unit == "年" && q.value >= 1000 {
+			return nil
+		}
 	}
-	if flag[3] {
-		t.tp[3] = cur.Hour()
+	return []string{expTime[match[0]:match[1]], expTime[match[2]:match[3]], expTime[match[4]:match[5]]}
+}
+
+// withoutBaseRelated 去掉时间偏移表达式后的文字
+func withoutBaseRelated(expTime string) string {
+	if match := baseRelatedMatch(expTime); match != nil {
+		return strings.Replace(expTime, match[0], "", 1)
 	}
+	return expTime
 }
 
-func (t *TimeUnit) calcNormSetBaseRelated(cur time.Time, reg string, flagIdx int, negtive bool) (time.Time, bool) {
-	var update bool
-	pattern := regexp2.MustCompile(reg, 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-		update = true
-		delta, _ := strconv.Atoi(match.String())
-		if negtive {
-			delta *= -1
+// withoutQuantities 去掉时间偏移和带小数的数量后的文字，避免其中的数字被识别为年份、月份或时间长度
+func withoutQuantities(expTime string) string {
+	expTime = withoutBaseRelated(expTime)
+	for _, q := range findQuantities(expTime) {
+		if q.fractional {
+			expTime = strings.Replace(expTime, q.text, "", 1)
 		}
-		switch flagIdx {
-		case 3:
-			cur = cur.Add(time.Duration(delta) * time.Hour)
-		case 2:
-			cur = cur.AddDate(0, 0, delta)
-		case 1:
-			cur = cur.AddDate(0, delta, 0)
-		case 0:
-			cur = cur.AddDate(delta, 0, 0)
+	}
+	return expTime
+}
+
+// normSetBaseRelated 设置以基准时间为参照的时间偏移计算，如“30分钟后”、“两周以前”、“两天半以后”
+// 偏移后的时间精确到偏移量的最小单位，如“2小时以前”精确到小时
+func (t *TimeUnit) normSetBaseRelated() {
+	match := baseRelatedMatch(t.expTime)
+	if match == nil {
+		return
+	}
+	var offset [6]int
+	pointer := -1
+	for _, q := range findQuantities(match[1]) {
+		if q.value > maxQuantity {
+			t.setErr(&RangeError{Field: "offset", Value: q.text, Min: 0, Max: maxQuantity})
+			return
+		}
+		fields, _ := q.fields()
+		for idx, v := range fields {
+			offset[idx] += v
+			if v != 0 && idx > pointer {
+				pointer = idx
+			}
+		}
+		if idx := quantityUnits[q.unit].Idx; idx > pointer {
+			pointer = idx
+		}
+		// 带小数或“半”的年、月偏移精确到日，如“一年半以后”
+		if q.fractional && pointer < 2 {
+			pointer = 2
+		}
+	}
+	if match[2] == "前" {
+		for idx := range offset {
+			offset[idx] *= -1
 		}
 	}
-	return cur, update
+	cur := t.state.ref.AddDate(offset[0], offset[1], offset[2])
+	cur = cur.Add(time.Duration(offset[3])*time.Hour + time.Duration(offset[4])*time.Minute + time.Duration(offset[5])*time.Second)
+	tp := NewTimePointFromTime(cur)
+	for idx := 0; idx <= pointer; idx++ {
+		t.tp[idx] = tp[idx]
+	}
 }
 
 // normSetCurRelated 设置当前时间相关的时间表达式
 func (t *TimeUnit) normSetCurRelated() {
-	cur := time.Now()
+	cur := t.state.ref
 	flag := []bool{false, false, false}
 	var updateFlag bool
 	cur, updateFlag = t.normSetCurRelatedYear(cur)
@@ -378,11 +721,10 @@ func (t *TimeUnit) calcNormSetCurRelatedYear(cur time.Time, word string, years i
 	return cur, false
 }
 
-func (t *TimeUnit) calcNormSetCurRelatedMonth(cur time.Time, reg string, char string, negtive bool) (time.Time, bool) {
-	pattern := regexp.MustCompile(reg)
-	if pattern.MatchString(t.expTime) {
+func (t *TimeUnit) calcNormSetCurRelatedMonth(cur time.Time, pattern *regexp.Regexp, char string, negtive bool) (time.Time, bool) {
+	if m := pattern.FindString(t.expTime); m != "" {
 		if char != "" {
-			cnt := strings.Count(t.expTime, char)
+			cnt := strings.Count(m, char)
 			if negtive {
 				cnt *= -1
 			}
@@ -393,20 +735,22 @@ func (t *TimeUnit) calcNormSetCurRelatedMonth(cur time.Time, reg string, char st
 	return cur, false
 }
 
+// curRelatedMonthRules 相对当前月份的规则
+var curRelatedMonthRules = []struct {
+	Pattern *regexp.Regexp
+	Char    string
+	Negtive bool
+}{
+	{Pattern: regexp.MustCompile(`上*上(个)?月`), Char: "上", Negtive: true},
+	{Pattern: regexp.MustCompile(`(本|这个)月`)},
+	{Pattern: regexp.MustCompile(`下*下(个)?月`), Char: "下"},
+}
+
 func (t *TimeUnit) normSetCurRelatedMonth(cur time.Time) (time.Time, bool) {
 	var updateFlag bool
-	monthSettings := []struct {
-		Reg     string
-		Char    string
-		Negtive bool
-	}{
-		{Reg: `上*上(个)?月`, Char: "上", Negtive: true},
-		{Reg: `(本|这个)月`},
-		{Reg: `下*下(个)?月`, Char: "下"},
-	}
-	for _, s := range monthSettings {
+	for _, s := range curRelatedMonthRules {
 		var matched bool
-		cur, matched = t.calcNormSetCurRelatedMonth(cur, s.Reg, s.Char, s.Negtive)
+		cur, matched = t.calcNormSetCurRelatedMonth(cur, s.Pattern, s.Char, s.Negtive)
 		if matched {
 			updateFlag = matched
 		}
@@ -414,25 +758,27 @@ func (t *TimeUnit) normSetCurRelatedMonth(cur time.Time) (time.Time, bool) {
 	return cur, updateFlag
 }
 
+// curRelatedDayRules 相对当前日期的规则，Pattern为空时按Char匹配
+var curRelatedDayRules = []struct {
+	Pattern *regexp2.Regexp
+	Char    string
+	Days    int
+}{
+	{Pattern: regexp2.MustCompile(`大*大前天`, 0), Char: "大", Days: 0},
+	{Pattern: regexp2.MustCompile("(?<!大)前天", 0), Days: -2},
+	{Pattern: regexp2.MustCompile("(?<!大)前天", 0), Days: -1},
+	{Char: "昨", Days: -1},
+	{Pattern: regexp2.MustCompile("今(?!年)", 0)},
+	{Pattern: regexp2.MustCompile("明(?!年)", 0), Days: 1},
+	{Pattern: regexp2.MustCompile("(?<!大)后天", 0), Days: 2},
+	{Pattern: regexp2.MustCompile(`大*大后天`, 0), Char: "大", Days: 2},
+}
+
 func (t *TimeUnit) normSetCurRelatedDay(cur time.Time) (time.Time, bool) {
 	var updateFlag bool
-	daysSettings := []struct {
-		Reg  string
-		Char string
-		Days int
-	}{
-		{Reg: `大*大前天`, Char: "大", Days: 0},
-		{Reg: "(?<!大)前天", Days: -2},
-		{Reg: "(?<!大)前天", Days: -1},
-		{Char: "昨", Days: -1},
-		{Reg: "今(?!年)"},
-		{Reg: "明(?!年)", Days: 1},
-		{Reg: "(?<!大)后天", Days: 2},
-		{Reg: `大*大后天`, Char: "大", Days: 2},
-	}
-	for _, s := range daysSettings {
+	for _, s := range curRelatedDayRules {
 		var matched bool
-		cur, matched = t.calcNormSetCurRelatedDay(cur, s.Reg, s.Char, s.Days)
+		cur, matched = t.calcNormSetCurRelatedDay(cur, s.Pattern, s.Char, s.Days)
 		if matched {
 			updateFlag = true
 		}
@@ -440,8 +786,8 @@ func (t *TimeUnit) normSetCurRelatedDay(cur time.Time) (time.Time, bool) {
 	return cur, updateFlag
 }
 
-func (t *TimeUnit) calcNormSetCurRelatedDay(cur time.Time, reg string, char string, days int) (time.Time, bool) {
-	if reg == "" {
+func (t *TimeUnit) calcNormSetCurRelatedDay(cur time.Time, pattern *regexp2.Regexp, char string, days int) (time.Time, bool) {
+	if pattern == nil {
 		if strings.Contains(t.expTime, char) {
 			cur = cur.AddDate(0, 0, days)
 			return cur, true
@@ -449,16 +795,14 @@ func (t *TimeUnit) calcNormSetCurRelatedDay(cur time.Time, reg string, char stri
 		return cur, false
 	}
 	if char != "" {
-		pattern := regexp.MustCompile(reg)
-		if pattern.MatchString(t.expTime) {
+		if t.match(pattern, t.expTime) {
 			cnt := strings.Count(t.expTime, char)
 			cur = cur.AddDate(0, 0, -1*(days+cnt))
 			return cur, true
 		}
 		return cur, false
 	}
-	pattern := regexp2.MustCompile(reg, 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+	if match := t.find(pattern, t.expTime); match != nil {
 		if days != 0 {
 			cur = cur.AddDate(0, 0, days)
 		}
@@ -467,23 +811,25 @@ func (t *TimeUnit) calcNormSetCurRelatedDay(cur time.Time, reg string, char stri
 	return cur, false
 }
 
+// curRelatedWeekRules 相对当前星期的规则
+var curRelatedWeekRules = []struct {
+	Pattern      *regexp2.Regexp
+	Char         string
+	Days         int
+	PreferFuture bool
+}{
+	{Pattern: regexp2.MustCompile(`(?<=(上*上上(周|星期)))[1-7]?`, 0), Char: "上", Days: -7},
+	{Pattern: regexp2.MustCompile(`(?<=((?<!上)上(周|星期)))[1-7]?`, 0), Days: -7},
+	{Pattern: regexp2.MustCompile(`(?<=((?<!下)下(周|星期)))[1-7]?`, 0), Days: 7},
+	{Pattern: regexp2.MustCompile(`(?<=(下*下下(周|星期)))[1-7]?`, 0), Char: "下", Days: 7}, // 这里对下下下周的时间转换做出了改善
+	{Pattern: regexp2.MustCompile(`(?<=((?<!(上|下|个|[0-9]))(周|星期)))[1-7]`, 0), Days: 0, PreferFuture: true},
+}
+
 func (t *TimeUnit) normSetCurRelatedWeek(cur time.Time) (time.Time, bool) {
 	var updateFlag bool
-	weekSettings := []struct {
-		Reg          string
-		Char         string
-		Days         int
-		PreferFuture bool
-	}{
-		{Reg: `(?<=(上*上上(周|星期)))[1-7]?`, Char: "上", Days: -7},
-		{Reg: `(?<=((?<!上)上(周|星期)))[1-7]?`, Days: -7},
-		{Reg: `(?<=((?<!下)下(周|星期)))[1-7]?`, Days: 7},
-		{Reg: `(?<=(下*下下(周|星期)))[1-7]?`, Char: "下", Days: 7}, // 这里对下下下周的时间转换做出了改善
-		{Reg: `(?<=((?<!(上|下|个|[0-9]))(周|星期)))[1-7]`, Days: 0, PreferFuture: true},
-	}
-	for _, s := range weekSettings {
+	for _, s := range curRelatedWeekRules {
 		var matched bool
-		cur, matched = t.calcNormSetCurRelatedWeek(cur, s.Reg, s.Char, s.Days, s.PreferFuture)
+		cur, matched = t.calcNormSetCurRelatedWeek(cur, s.Pattern, s.Char, s.Days, s.PreferFuture)
 		if matched {
 			updateFlag = true
 		}
@@ -491,9 +837,8 @@ func (t *TimeUnit) normSetCurRelatedWeek(cur time.Time) (time.Time, bool) {
 	return cur, updateFlag
 }
 
-func (t *TimeUnit) calcNormSetCurRelatedWeek(cur time.Time, reg string, char string, days int, preferFuture bool) (time.Time, bool) {
-	pattern := regexp2.MustCompile(reg, 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+func (t *TimeUnit) calcNormSetCurRelatedWeek(cur time.Time, pattern *regexp2.Regexp, char string, days int, preferFuture bool) (time.Time, bool) {
+	if match := t.find(pattern, t.expTime); match != nil {
 		week, err := strconv.Atoi(match.String())
 		if err != nil {
 			week = 1
@@ -505,7 +850,7 @@ func (t *TimeUnit) calcNormSetCurRelatedWeek(cur time.Time, reg string, char str
 		if char != "" {
 			cnt = strings.Count(t.expTime, char)
 		}
-		span := (week - int(cur.Weekday())) + days*cnt
+		span := (t.normalizer.weekOffset(week) - t.normalizer.weekOffset(int(cur.Weekday()))) + days*cnt
 		cur = cur.AddDate(0, 0, span)
 		if preferFuture {
 			// 处理未来时间
@@ -516,11 +861,363 @@ func (t *TimeUnit) calcNormSetCurRelatedWeek(cur time.Time, reg string, char str
 	return cur, false
 }
 
+// yearPartPattern 年内时段，如“年初”、“下半年”
+var yearPartPattern = regexp2.MustCompile(`年([初中底末])(?![\d国华文央心秋午])|([上下])半年`, 0)
+
+// yearParts 年内时段的起始月份和包含的月数
+var yearParts = map[string][2]int{
+	"初": {1, 1},
+	"中": {6, 2},
+	"底": {12, 1},
+	"末": {12, 1},
+	"上": {1, 6},
+	"下": {7, 6},
+}
+
+// normSetYearPart 年内时段，月份取时段的第一个月，区间为时段内的所有月份
+func (t *TimeUnit) normSetYearPart() {
+	if t.tp[1] != -1 {
+		return
+	}
+	match := t.find(yearPartPattern, t.expTime)
+	if match == nil {
+		return
+	}
+	var part [2]int
+	if g := match.GroupByNumber(2); g.Length > 0 {
+		part = yearParts[g.String()]
+	} else {
+		part = yearParts[match.GroupByNumber(1).String()]
+	}
+	// 倾向未来时间时，只有时段已经完全过去才顺延到下一年
+	t.tp[1] = part[0] + part[1]
+	t.preferFuture(1)
+	t.tp[1] = part[0]
+	t.months = part[1]
+	t.sources[1] = FIELD_EXPLICIT
+}
+
+var (
+	// weekdayOrdinalPattern 区间内第几个星期几或工作日，如“第2个星期7”、“最后1个周5”、“倒数第2个工作日”
+	weekdayOrdinalPattern = regexp.MustCompile(`(?:(倒数)?第(\d+)个|最后1?个)(?:(?:周|星期|礼拜)([1-7])|工作日)`)
+	// dayOrdinalPattern 区间内第几天，如“第100天”、“最后1天”、“倒数第3天”
+	dayOrdinalPattern = regexp.MustCompile(`(?:(倒数)?第(\d+)|最后1?)天`)
+	// lastWeekPattern 区间内的最后一周，如“年底前最后1周”
+	lastWeekPattern = regexp2.MustCompile(`最后1?个?(?:周|星期|礼拜)(?![1-7])`, 0)
+)
+
+// weekdayOrdinal 第几个星期几或第几天的序号，倒数的为负数
+func (t *TimeUnit) weekdayOrdinal(match []string) int {
+	if match[2] == "" {
+		return -1
+	}
+	nth := t.atoi("ordinal", match[2], 1, 366)
+	if match[1] != "" {
+		return -nth
+	}
+	return nth
+}
+
+// ordinalPeriod 区间内序数的参照区间，依次为已识别出的日期区间、月份（季度等为多个月）和年份，
+// 都没有时wholeYear为true取本年，否则取本月，given为false
+func (t *TimeUnit) ordinalPeriod(wholeYear bool) (start time.Time, end time.Time, given bool) {
+	loc := t.state.timeBase.Location()
+	base := NewTimePointFromTime(t.state.timeBase)
+	year := base[0]
+	if t.tp[0] != -1 {
+		year = t.tp[0]
+	}
+	switch {
+	case t.tp[2] != -1:
+		start = time.Date(year, time.Month(t.tp[1]), t.tp[2], 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, t.days), true
+	case t.tp[1] != -1:
+		months := t.months
+		if months == 0 {
+			months = 1
+		}
+		start = time.Date(year, time.Month(t.tp[1]), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, months, 0), true
+	case t.tp[0] != -1 || wholeYear:
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0), t.tp[0] != -1
+	}
+	start = time.Date(year, time.Month(base[1]), 1, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 1, 0), false
+}
+
+// normSetPeriodOrdinal 区间内第几天、第几个星期几或工作日及最后一周，如“本月最后1天”、“下个月倒数第3天”、
+// “今年最后1个工作日”、“5月第2个星期日”、“年底前最后1周”，区间可以是已识别出的月份、季度、年份或整周，
+// 没有给出区间时“第100天”为本年的第100天，其余为本月；工作日不包括周六、周日和WithHolidayCalendar设置的节假日
+func (t *TimeUnit) normSetPeriodOrdinal() {
+	if t.tp[2] != -1 && t.days == 0 {
+		return
+	}
+	var (
+		find  func(start, end time.Time) (day time.Time, count int)
+		field string
+		nth   int
+		days  int
+		year  bool
+	)
+	if match := weekdayOrdinalPattern.FindStringSubmatch(t.expTime); match != nil {
+		field, nth = "weekday ordinal", t.weekdayOrdinal(match)
+		find = func(start, end time.Time) (time.Time, int) {
+			return t.normalizer.nthWeekday(start, end, match[3], nth)
+		}
+	} else if match := dayOrdinalPattern.FindStringSubmatch(t.expTime); match != nil {
+		field, nth, year = "day ordinal", t.weekdayOrdinal(match), match[2] != "" && match[1] == ""
+		find = func(start, end time.Time) (time.Time, int) {
+			return nthDay(start, end, nth)
+		}
+	} else if t.match(lastWeekPattern, t.expTime) {
+		field, nth, days = "week", -1, 7
+		find = func(start, end time.Time) (time.Time, int) {
+			if end.Sub(start) < 7*24*time.Hour {
+				return time.Time{}, 0
+			}
+			return end.AddDate(0, 0, -7), 1
+		}
+	} else {
+		return
+	}
+	start, end, given := t.ordinalPeriod(year)
+	day, count := find(start, end)
+	// 倾向未来时间时，没有给出区间且日期已经过去则顺延到下一个区间
+	if !given && t.normalizer.isPreferFuture && !day.IsZero() && day.AddDate(0, 0, 1).Before(t.state.timeBase) {
+		if year {
+			start, end = start.AddDate(1, 0, 0), end.AddDate(1, 0, 0)
+		} else {
+			start, end = start.AddDate(0, 1, 0), end.AddDate(0, 1, 0)
+		}
+		day, count = find(start, end)
+	}
+	if day.IsZero() {
+		t.setErr(&RangeError{Field: field, Value: strconv.Itoa(nth), Min: -count, Max: count})
+		return
+	}
+	t.tp[0] = day.Year()
+	t.tp[1] = int(day.Month())
+	t.tp[2] = day.Day()
+	t.months = 0
+	t.days = days
+}
+
+// weekOrdinalPattern 第几周，如“2025年第35周”、“11月第3周”
+var weekOrdinalPattern = regexp.MustCompile(`第(\d+)周`)
+
+// normSetWeekOrdinal 第几周，给出月份时按WithWeekOfMonth设置的规则计算月内的周，否则为ISO 8601的周，区间为整周
+func (t *TimeUnit) normSetWeekOrdinal() {
+	match := weekOrdinalPattern.FindStringSubmatch(t.expTime)
+	if match == nil || t.tp[2] != -1 {
+		return
+	}
+	week := t.atoi("week", match[1], 1, 53)
+	if t.err != nil {
+		return
+	}
+	n := t.normalizer
+	loc := t.state.timeBase.Location()
+	base := NewTimePointFromTime(t.state.timeBase)
+	year, yearGiven := base[0], t.tp[0] != -1
+	if yearGiven {
+		year = t.tp[0]
+	}
+	var (
+		start time.Time
+		days  int
+		max   int
+	)
+	if t.tp[1] != -1 {
+		start, days, max = n.weekOfMonth(year, time.Month(t.tp[1]), week, loc)
+	} else {
+		max = isoWeeksInYear(year)
+		if week >= 1 && week <= max {
+			start, days = isoWeekStart(year, week, loc), 7
+			// 倾向未来时间时，没有给出年份且该周已经过去则顺延到下一年
+			if !yearGiven && n.isPreferFuture && !start.AddDate(0, 0, days).After(t.state.timeBase) && week <= isoWeeksInYear(year+1) {
+				start = isoWeekStart(year+1, week, loc)
+			}
+		}
+	}
+	if week < 1 || week > max {
+		t.setErr(&RangeError{Field: "week", Value: match[1], Min: 1, Max: max})
+		return
+	}
+	t.tp[0] = start.Year()
+	t.tp[1] = int(start.Month())
+	t.tp[2] = start.Day()
+	t.days = days
+}
+
+var (
+	// quarterPattern 季度，如“第2季度”、“2025Q3”、“下季度末”
+	quarterPattern = regexp2.MustCompile(`(?:第([1-4])季度?|(?<![前\d])([1-4])季度|[Qq]([1-4])(?!\d)|(上*上|下*下|本|这)个?季度|季度?(?=[初末底]))([初末底])?`, 0)
+	// firstQuartersPattern 财年的前几个季度，如“前3季度”
+	firstQuartersPattern = regexp.MustCompile(`前([1-3])季度`)
+	// fiscalYearPattern 财年，如“2025财年”、“上个财年”
+	fiscalYearPattern = regexp2.MustCompile(`(\d+)财年|([本今这]|上*上|下*下)?个?财年`, 0)
+)
+
+// normSetQuarter 季度和财年，季度按财年划分，区间为季度或财年内的所有月份
+func (t *TimeUnit) normSetQuarter() {
+	if t.tp[1] != -1 {
+		return
+	}
+	quarter := t.find(quarterPattern, t.expTime)
+	first := firstQuartersPattern.FindStringSubmatch(t.expTime)
+	fiscal := t.find(fiscalYearPattern, t.expTime)
+	if quarter == nil && first == nil && fiscal == nil {
+		return
+	}
+	n := t.normalizer
+	ref := t.state.ref
+	year, yearGiven := n.fiscalYear(t.state.timeBase), t.tp[0] != -1
+	if yearGiven {
+		year = t.tp[0]
+	}
+	if fiscal != nil {
+		yearGiven = true
+		if g := fiscal.GroupByNumber(1); g.Length > 0 {
+			year = t.atoi("fiscal year", g.String(), 0, maxYear)
+		} else {
+			year = n.fiscalYear(ref) + relativeCount(fiscal.GroupByNumber(2).String())
+		}
+	}
+	if year < 1 || year > maxYear {
+		t.setErr(&RangeError{Field: "year", Value: strconv.Itoa(year), Min: 1, Max: maxYear})
+		return
+	}
+	start := n.fiscalYearStart(year, ref.Location())
+	months := 12
+	if first != nil {
+		months = 3 * t.atoi("quarter", first[1], 1, 4)
+	} else if quarter != nil {
+		months = 3
+		var q int
+		for i := 1; i <= 3; i++ {
+			if g := quarter.GroupByNumber(i); g.Length > 0 {
+				q = t.atoi("quarter", g.String(), 1, 4)
+			}
+		}
+		if q == 0 {
+			// “上季度”、“季末”等相对参考时间所在的季度
+			start = n.quarterStart(ref).AddDate(0, 3*relativeCount(quarter.GroupByNumber(4).String()), 0)
+		} else {
+			start = start.AddDate(0, 3*(q-1), 0)
+			// 倾向未来时间时，没有给出年份且季度已经过去则顺延到下一年
+			if !yearGiven && n.isPreferFuture && !start.AddDate(0, 3, 0).After(t.state.timeBase) {
+				start = start.AddDate(1, 0, 0)
+			}
+		}
+		switch quarter.GroupByNumber(5).String() {
+		case "初":
+			months = 1
+		case "末", "底":
+			start = start.AddDate(0, 2, 0)
+			months = 1
+		}
+	}
+	t.tp[0] = start.Year()
+	t.tp[1] = int(start.Month())
+	t.months = months
+	t.sources[1] = FIELD_EXPLICIT
+}
+
+// relativeCount 相对时间的偏移量，如“下下”为2，“上”为-1，“本”为0
+func relativeCount(word string) int {
+	return strings.Count(word, "下") - strings.Count(word, "上")
+}
+
+// monthPartPattern 月内时段，如“上旬”、“月初”、“3月底”
+var monthPartPattern = regexp2.MustCompile(`([上中下])旬|月([初中底末])(?![\d国华文央心秋午旬])`, 0)
+
+// normSetMonthPart 月内时段，日期取时段的第一天，区间为时段内的所有日期
+func (t *TimeUnit) normSetMonthPart() {
+	if t.tp[2] != -1 {
+		return
+	}
+	match := t.find(monthPartPattern, t.expTime)
+	if match == nil {
+		return
+	}
+	var part string
+	if g := match.GroupByNumber(1); g.Length > 0 {
+		part = g.String() + "旬"
+	} else {
+		part = "月" + strings.Replace(match.GroupByNumber(2).String(), "末", "底", 1)
+	}
+	// 倾向未来时间时，只有时段已经完全过去才顺延到下个月
+	base := NewTimePointFromTime(t.state.timeBase)
+	year, month := base[0], base[1]
+	_, last := t.normalizer.monthPartDays(part, year, time.Month(month))
+	t.tp[2] = last + 1
+	t.preferFuture(2)
+	if t.tp[0] != -1 {
+		year = t.tp[0]
+	}
+	if t.tp[1] != -1 {
+		month = t.tp[1]
+	}
+	first, last := t.normalizer.monthPartDays(part, year, time.Month(month))
+	t.tp[2] = first
+	t.days = last - first + 1
+}
+
+var (
+	// weekPattern 相对当前星期的整周，如“本周”、“下下周”
+	weekPattern = regexp2.MustCompile(`(上*上|下*下|本|这)个?(?:周|星期|礼拜)(?![1-7])`, 0)
+	// weekPartPattern 一周内的时段，如“周末”、“工作日”、“本周内”
+	weekPartPattern = regexp2.MustCompile(`(?:周|星期|礼拜)([末内])|工作日`, 0)
+)
+
+// normSetWeekRange 整周及一周内的时段，日期取时段的第一天，区间为时段内的所有日期
+// 每周的第一天由WithWeekStart设置，周末为该周的周六和随后的周日，工作日为周一至周五，“本周内”为今天至本周结束
+func (t *TimeUnit) normSetWeekRange() {
+	week := t.find(weekPattern, t.expTime)
+	part := t.find(weekPartPattern, t.expTime)
+	if part != nil && weekdayOrdinalPattern.MatchString(t.expTime) {
+		// “第1个工作日”等在normSetPeriodOrdinal中处理
+		part = nil
+	}
+	if week == nil && (part == nil || t.tp[2] != -1) {
+		return
+	}
+	n := t.normalizer
+	ref := t.state.ref
+	today := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+	offset := 0
+	if week != nil {
+		offset = relativeCount(week.GroupByNumber(1).String())
+	}
+	weekday := n.weekOffset(int(today.Weekday()))
+	start := today.AddDate(0, 0, 7*offset-weekday)
+	days := 7
+	switch {
+	case part == nil:
+	case part.String() == "工作日":
+		start, days = start.AddDate(0, 0, n.weekOffset(int(time.Monday))), 5
+	case part.GroupByNumber(1).String() == "末":
+		start, days = start.AddDate(0, 0, n.weekOffset(int(time.Saturday))), 2
+	case offset == 0:
+		start, days = today, 7-weekday
+	}
+	// 倾向未来时间时，没有给出相对星期的时段已经完全过去则顺延到下一周
+	if week == nil && n.isPreferFuture && !start.AddDate(0, 0, days).After(t.state.timeBase) {
+		start = start.AddDate(0, 0, 7)
+	}
+	t.tp[0] = start.Year()
+	t.tp[1] = int(start.Month())
+	t.tp[2] = start.Day()
+	t.days = days
+}
+
+var hourPattern = regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9])(?=(点|时))", 0)
+
 // normSetHour 时-规范化方法：该方法识别时间表达式单元的时字段
 func (t *TimeUnit) normSetHour() {
-	pattern := regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9])(?=(点|时))", 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-		h, _ := strconv.Atoi(match.String())
+	if match := t.find(hourPattern, t.expTime); match != nil {
+		h := t.atoi("hour", match.String(), 0, 24)
 		t.tp[3] = h
 		t.normCheckKeyword()
 		t.preferFuture(3)
@@ -530,149 +1227,177 @@ func (t *TimeUnit) normSetHour() {
 	}
 }
 
+var (
+	minutePattern             = regexp2.MustCompile("([0-9]+(?=分(?!钟)))|((?<=((?<!小)[点时]))[0-5]?[0-9](?!刻))", 0)
+	minuteOneQuarterPattern   = regexp2.MustCompile("(?<=[点时])[1一]刻(?!钟)", 0)
+	minuteHalfPattern         = regexp2.MustCompile("(?<=[点时])半", 0)
+	minuteThreeQuarterPattern = regexp2.MustCompile("(?<=[点时])[3三]刻(?!钟)", 0)
+)
+
 // normSetMinute 分-规范化方法：该方法识别时间表达式单元的分字段
 func (t *TimeUnit) normSetMinute() {
 	{
-		pattern := regexp2.MustCompile("([0-9]+(?=分(?!钟)))|((?<=((?<!小)[点时]))[0-5]?[0-9](?!刻))", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			if minute, err := strconv.Atoi(match.String()); err == nil {
-				t.tp[4] = minute
-				t.isAllDayTime = false
-			}
+		if match := t.find(minutePattern, t.expTime); match != nil {
+			t.tp[4] = t.atoi("minute", match.String(), 0, 59)
+			t.isAllDayTime = false
 		}
 	}
 	{
-		pattern := regexp2.MustCompile("(?<=[点时])[1一]刻(?!钟)", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+		if match := t.find(minuteOneQuarterPattern, t.expTime); match != nil {
 			t.tp[4] = 15
 			t.isAllDayTime = false
 		}
 	}
 	{
-		pattern := regexp2.MustCompile("(?<=[点时])半", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+		if match := t.find(minuteHalfPattern, t.expTime); match != nil {
 			t.tp[4] = 30
 			t.isAllDayTime = false
 		}
 	}
 	{
-		pattern := regexp2.MustCompile("(?<=[点时])[3三]刻(?!钟)", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+		if match := t.find(minuteThreeQuarterPattern, t.expTime); match != nil {
 			t.tp[4] = 45
 			t.isAllDayTime = false
 		}
 	}
 }
 
+var secondPattern = regexp2.MustCompile("([0-9]+(?=秒(?!钟?[以之]?[前后])))|((?<=分)[0-5]?[0-9])", 0)
+
+var (
+	// doubleHourPattern 时辰及刻，如“子时”、“午时3刻”，时辰前须有日期或时段，或后接“刻”，或前后均不是汉字，
+	// 避免“电子时钟”、“重申时”、“生辰时间”等误识别
+	doubleHourPattern = regexp2.MustCompile(`(?:(?<=\d[日号]|[今明后昨前][天日]|[早晚]上|[今明昨]晚|夜里|凌晨|[半深]夜)|(?=[子丑寅卯辰巳午未申酉戌亥]时\d刻)|(?<=^|[^\p{Han}]|[到至])(?=[子丑寅卯辰巳午未申酉戌亥]时(?:$|[^\p{Han}]|[到至])))(?<![孩儿样阵上中下])([子丑寅卯辰巳午未申酉戌亥])时(?![候期代好])(?:([1-7])刻)?`, 0)
+	// nightWatchPattern 更，如“3更半夜”、“5更”
+	nightWatchPattern = regexp2.MustCompile(`(?<!\d)([1-5])更(?![新改换正多])`, 0)
+)
+
+// doubleHours 时辰开始的小时，“子时”为23:00-01:00
+var doubleHours = map[string]int{
+	"子": 23, "丑": 1, "寅": 3, "卯": 5, "辰": 7, "巳": 9,
+	"午": 11, "未": 13, "申": 15, "酉": 17, "戌": 19, "亥": 21,
+}
+
+// normSetDoubleHour 时辰和更，时辰、更均为2小时，“1更”为19:00-21:00，依次至“5更”为03:00-05:00；
+// 时辰后的“刻”为15分钟，如“午时3刻”为11:45-12:00，子时、3更开始于当天23:00
+func (t *TimeUnit) normSetDoubleHour() {
+	var hour int
+	if match := t.find(doubleHourPattern, t.expTime); match != nil {
+		hour = doubleHours[match.GroupByNumber(1).String()]
+		t.tp[3] = hour
+		if quarter := match.GroupByNumber(2).String(); quarter != "" {
+			q := t.atoi("quarter", quarter, 1, 7)
+			t.tp[4], t.window = 15*q, 15*time.Minute
+		} else if t.tp[4] == -1 {
+			t.window = 2 * time.Hour
+		}
+	} else if match := t.find(nightWatchPattern, t.expTime); match != nil {
+		watch := t.atoi("watch", match.GroupByNumber(1).String(), 1, 5)
+		t.tp[3], t.tp[4], t.window = (17+2*watch)%24, -1, 2*time.Hour
+	} else {
+		return
+	}
+	t.period = 0
+	t.isAllDayTime = false
+	t.preferFuture(3)
+}
+
 // normSetSecond 添加了省略“秒”说法的时间：如17点15分32
 func (t *TimeUnit) normSetSecond() {
-	pattern := regexp2.MustCompile("([0-9]+(?=秒))|((?<=分)[0-5]?[0-9])", 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-		sec, _ := strconv.Atoi(match.String())
+	if match := t.find(secondPattern, t.expTime); match != nil {
+		sec := t.atoi("second", match.String(), 0, 59)
 		t.tp[5] = sec
 		t.isAllDayTime = false
 	}
 }
 
+var (
+	clockHMSPattern = regexp.MustCompile(`([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]`)
+	clockHMPattern  = regexp.MustCompile(`([0-2]?[0-9]):[0-5]?[0-9]`)
+)
+
+// specialRules 特殊形式的时间规则，Time为空时Pattern匹配的即为24小时制时间
+var specialRules = []struct {
+	Pattern *regexp2.Regexp
+	Time    *regexp.Regexp
+	HasSec  bool
+}{
+	{
+		Pattern: regexp2.MustCompile("(晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后)(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]", 0),
+		Time:    clockHMSPattern,
+		HasSec:  true,
+	},
+	{
+		Pattern: regexp2.MustCompile("(晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后)(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]", 0),
+		Time:    clockHMPattern,
+		HasSec:  false,
+	},
+	{
+		Pattern: regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9](PM|pm|p\\.m)", 0),
+		Time:    clockHMSPattern,
+		HasSec:  true,
+	},
+	{
+		Pattern: regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9](PM|pm|p.m)", 0),
+		Time:    clockHMSPattern,
+		HasSec:  false,
+	},
+	{
+		Pattern: regexp2.MustCompile("(?<!(周|星期|晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]", 0),
+		HasSec:  true,
+	},
+	{
+		Pattern: regexp2.MustCompile("(?<!(周|星期|晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后))([0-2]?[0-9]):[0-5]?[0-9]", 0),
+		HasSec:  false,
+	},
+}
+
+// specialYearRules 年-月-日形式的日期规则
+var specialYearRules = []struct {
+	Pattern *regexp2.Regexp
+	Spliter string
+}{
+	{Pattern: regexp2.MustCompile("[0-9]?[0-9]?[0-9]{2}-((10)|(11)|(12)|([1-9]))-((?<!\\d))([0-3][0-9]|[1-9])", 0), Spliter: "-"},
+	{Pattern: regexp2.MustCompile("[0-9]?[0-9]?[0-9]{2}/((10)|(11)|(12)|([1-9]))/((?<!\\d))([0-3][0-9]|[1-9])", 0), Spliter: "/"},
+	{Pattern: regexp2.MustCompile("[0-9]?[0-9]?[0-9]{2}\\.((10)|(11)|(12)|([1-9]))\\.((?<!\\d))([0-3][0-9]|[1-9])", 0), Spliter: "."},
+}
+
 // normSetSpecial 特殊形式的规范化方法-该方法识别特殊形式的时间表达式单元的各个字段
 func (t *TimeUnit) normSetSpecial() {
-	cases := []struct {
-		Regs   []string
-		HasSec bool
-	}{
-		{
-			Regs: []string{
-				"(晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后)(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]",
-				`([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]`,
-			},
-			HasSec: true,
-		},
-		{
-			Regs: []string{
-				"(晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后)(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]",
-				`([0-2]?[0-9]):[0-5]?[0-9]`,
-			},
-			HasSec: false,
-		},
-		{
-			Regs: []string{
-				"(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9](PM|pm|p\\.m)",
-				`([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]`,
-			},
-			HasSec: true,
-		},
-		{
-			Regs: []string{
-				"(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9](PM|pm|p.m)",
-				`([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]`,
-			},
-			HasSec: false,
-		},
-		{
-			Regs:   []string{"(?<!(周|星期|晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]"},
-			HasSec: true,
-		},
-		{
-			Regs:   []string{"(?<!(周|星期|晚上|夜间|夜里|今晚|明晚|晚|夜里|下午|午后))([0-2]?[0-9]):[0-5]?[0-9]"},
-			HasSec: false,
-		},
-	}
-	for _, c := range cases {
-		if matched := t.calcNormSetSpecial(c.Regs, c.HasSec); matched {
+	for _, c := range specialRules {
+		if matched := t.calcNormSetSpecial(c.Pattern, c.Time, c.HasSec); matched {
 			return
 		}
 	}
-	yearSettings := []struct {
-		Reg     string
-		Spliter string
-	}{
-		{Reg: "[0-9]?[0-9]?[0-9]{2}-((10)|(11)|(12)|([1-9]))-((?<!\\d))([0-3][0-9]|[1-9])", Spliter: "-"},
-		{Reg: "[0-9]?[0-9]?[0-9]{2}/((10)|(11)|(12)|([1-9]))/((?<!\\d))([0-3][0-9]|[1-9])", Spliter: "/"},
-		{Reg: "[0-9]?[0-9]?[0-9]{2}\\.((10)|(11)|(12)|([1-9]))\\.((?<!\\d))([0-3][0-9]|[1-9])", Spliter: "."},
-	}
-	for _, s := range yearSettings {
-		t.calcNormSetSpecialYear(s.Reg, s.Spliter)
+	for _, s := range specialYearRules {
+		t.calcNormSetSpecialYear(s.Pattern, s.Spliter)
 	}
 }
 
-func (t *TimeUnit) calcNormSetSpecial(regs []string, hasSec bool) bool {
-	pattern := regexp2.MustCompile(regs[0], 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-		if len(regs) == 1 {
+func (t *TimeUnit) calcNormSetSpecial(pattern *regexp2.Regexp, timePattern *regexp.Regexp, hasSec bool) bool {
+	if match := t.find(pattern, t.expTime); match != nil {
+		if timePattern == nil {
 			parts := strings.Split(match.String(), ":")
-			if h, err := strconv.Atoi(parts[0]); err == nil {
-				t.tp[3] = h
-			}
-			if minute, err := strconv.Atoi(parts[1]); err == nil {
-				t.tp[4] = minute
-			}
+			t.tp[3] = t.atoi("hour", parts[0], 0, 24)
+			t.tp[4] = t.atoi("minute", parts[1], 0, 59)
 			if hasSec {
-				if sec, err := strconv.Atoi(parts[2]); err == nil {
-					t.tp[5] = sec
-				}
+				t.tp[5] = t.atoi("second", parts[2], 0, 59)
 			}
 			t.preferFuture(3)
 			t.isAllDayTime = false
 			return true
 		}
-		re := regexp.MustCompile(regs[1])
-		match := re.FindAllString(t.expTime, -1)
+		match := timePattern.FindAllString(t.expTime, -1)
 		for _, m := range match {
 			parts := strings.Split(m, ":")
-			if h, err := strconv.Atoi(parts[0]); err == nil {
-				if h >= 0 && h <= 11 {
-					t.tp[3] = h + 12
-				} else {
-					t.tp[3] = h
-				}
-			}
-			if minute, err := strconv.Atoi(parts[1]); err == nil {
-				t.tp[4] = minute
+			if h := t.atoi("hour", parts[0], 0, 24); h >= 0 && h <= 11 {
+				t.tp[3] = h + 12
+			} else {
+				t.tp[3] = h
 			}
+			t.tp[4] = t.atoi("minute", parts[1], 0, 59)
 			if hasSec {
-				if sec, err := strconv.Atoi(parts[2]); err == nil {
-					t.tp[5] = sec
-				}
+				t.tp[5] = t.atoi("second", parts[2], 0, 59)
 			}
 			t.preferFuture(3)
 			t.isAllDayTime = false
@@ -683,60 +1408,84 @@ func (t *TimeUnit) calcNormSetSpecial(regs []string, hasSec bool) bool {
 	return false
 }
 
-func (t *TimeUnit) calcNormSetSpecialYear(reg string, spliter string) {
-	pattern := regexp2.MustCompile(reg, 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+func (t *TimeUnit) calcNormSetSpecialYear(pattern *regexp2.Regexp, spliter string) {
+	if match := t.find(pattern, t.expTime); match != nil {
 		parts := strings.Split(match.String(), spliter)
-		if year, err := strconv.Atoi(parts[0]); err == nil {
-			t.tp[0] = year
-		}
-		if month, err := strconv.Atoi(parts[1]); err == nil {
-			t.tp[1] = month
-		}
-		if day, err := strconv.Atoi(parts[2]); err == nil {
-			t.tp[2] = day
-		}
+		t.tp[0] = t.atoi("year", parts[0], 0, maxYear)
+		t.tp[1] = t.atoi("month", parts[1], 1, 12)
+		t.tp[2] = t.atoi("day", parts[2], 1, 31)
 	}
 }
 
+// spanRelatedRules 时间长度规则
+var spanRelatedRules = []struct {
+	Pattern *regexp2.Regexp
+	Idx     int
+	AddWeek bool
+}{
+	{Pattern: regexp2.MustCompile("\\d+(?=个月(?![以之]?[前后]))", 0), Idx: 1},
+	{Pattern: regexp2.MustCompile("(?<![第\\d]|最后)\\d+(?=天(?![以之]?[前后]))", 0), Idx: 2},
+	{Pattern: regexp2.MustCompile("\\d+(?=(个)?小时(?![以之]?[前后]))", 0), Idx: 3},
+	{Pattern: regexp2.MustCompile(`\d+(?=分钟(?![以之]?[前后]))`, 0), Idx: 4},
+	{Pattern: regexp2.MustCompile(`\d+(?=秒钟(?![以之]?[前后]))`, 0), Idx: 5},
+	{Pattern: regexp2.MustCompile(`(?<![第\d]|最后)\d+(?=(个)?(周|星期|礼拜)(?![以之]?[前后]))`, 0), Idx: 2, AddWeek: true},
+}
+
 // normSetSpanRelated 设置时间长度相关的时间表达式
 func (t *TimeUnit) normSetSpanRelated() {
-	cases := []struct {
-		Reg     string
-		Idx     int
-		AddWeek bool
-	}{
-		{Reg: "\\d+(?=个月(?![以之]?[前后]))", Idx: 1},
-		{Reg: "\\d+(?=天(?![以之]?[前后]))", Idx: 2},
-		{Reg: "\\d+(?=(个)?小时(?![以之]?[前后]))", Idx: 3},
-		{Reg: `\d+(?=分钟(?![以之]?[前后]))`, Idx: 4},
-		{Reg: `\d+(?=秒钟(?![以之]?[前后]))`, Idx: 5},
-		{Reg: `(?<!第)\d+(?=(个)?(周|星期|礼拜)(?![以之]?[前后]))`, Idx: 2, AddWeek: true},
-	}
-	for _, c := range cases {
-		pattern := regexp2.MustCompile(c.Reg, 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			t.normalizer.isTimeSpan = true
-			value, _ := strconv.Atoi(match.String())
+	// 时间偏移已在normSetBaseRelated中处理，带小数的数量在normSetFractionalSpan中处理
+	expTime := withoutQuantities(t.expTime)
+	for _, c := range spanRelatedRules {
+		if match := t.find(c.Pattern, expTime); match != nil {
+			t.isTimeSpan = true
+			value := t.atoi("duration", match.String(), 0, maxQuantity)
 			if c.AddWeek {
 				if t.tp[2] == -1 {
 					t.tp[2] = 0
 				}
 				t.tp[2] += value * 7
+				t.weeks += value
 			} else {
 				t.tp[c.Idx] = value
 			}
 		}
 	}
+	t.normSetFractionalSpan()
 }
 
+// normSetFractionalSpan 带小数或“半”的时间长度，如“1个半小时”、“两天半”、“半年”，与其他时间长度累加
+func (t *TimeUnit) normSetFractionalSpan() {
+	for _, q := range findQuantities(withoutBaseRelated(t.expTime)) {
+		if !q.fractional {
+			continue
+		}
+		if q.value > maxQuantity {
+			t.setErr(&RangeError{Field: "duration", Value: q.text, Min: 0, Max: maxQuantity})
+			return
+		}
+		t.isTimeSpan = true
+		fields, weeks := q.fields()
+		for idx, v := range fields {
+			if v == 0 {
+				continue
+			}
+			if t.tp[idx] == -1 {
+				t.tp[idx] = 0
+			}
+			t.tp[idx] += v
+		}
+		t.weeks += weeks
+	}
+}
+
+var holidayPattern = regexp.MustCompile("(情人节)|(母亲节)|(青年节)|(教师节)|(中元节)|(端午)|(劳动节)|(7夕)|(建党节)|(建军节)|(初13)|(初14)|(初15)|(初12)|(初11)|(初9)|(初8)|(初7)|(初6)|(初5)|(初4)|(初3)|(初2)|(初1)|(中和节)|(圣诞)|(中秋)|(春节)|(元宵)|(航海日)|(儿童节)|(国庆)|(植树节)|(元旦)|(重阳节)|(妇女节)|(记者节)|(立春)|(雨水)|(惊蛰)|(春分)|(清明)|(谷雨)|(立夏)|(小满 )|(芒种)|(夏至)|(小暑)|(大暑)|(立秋)|(处暑)|(白露)|(秋分)|(寒露)|(霜降)|(立冬)|(小雪)|(大雪)|(冬至)|(小寒)|(大寒)")
+
 // normSetHoliday 节假日相关
 func (t *TimeUnit) normSetHoliday() {
-	pattern := regexp.MustCompile("(情人节)|(母亲节)|(青年节)|(教师节)|(中元节)|(端午)|(劳动节)|(7夕)|(建党节)|(建军节)|(初13)|(初14)|(初15)|(初12)|(初11)|(初9)|(初8)|(初7)|(初6)|(初5)|(初4)|(初3)|(初2)|(初1)|(中和节)|(圣诞)|(中秋)|(春节)|(元宵)|(航海日)|(儿童节)|(国庆)|(植树节)|(元旦)|(重阳节)|(妇女节)|(记者节)|(立春)|(雨水)|(惊蛰)|(春分)|(清明)|(谷雨)|(立夏)|(小满 )|(芒种)|(夏至)|(小暑)|(大暑)|(立秋)|(处暑)|(白露)|(秋分)|(寒露)|(霜降)|(立冬)|(小雪)|(大雪)|(冬至)|(小寒)|(大寒)")
-	match := pattern.FindAllString(t.expTime, -1)
+	match := holidayPattern.FindAllString(t.expTime, -1)
 	for _, holi := range match {
 		if t.tp[0] == -1 {
-			t.tp[0] = t.normalizer.timeBase.Year()
+			t.tp[0] = t.state.timeBase.Year()
 		}
 		if !strings.HasSuffix(holi, "节") {
 			holi += "节"
@@ -744,19 +1493,22 @@ func (t *TimeUnit) normSetHoliday() {
 		date := make([]int, 2)
 		if solar, found := t.normalizer.holiSolar[holi]; found {
 			arr := strings.Split(solar, "-")
-			date[0], _ = strconv.Atoi(arr[0])
-			date[1], _ = strconv.Atoi(arr[1])
+			date[0] = t.atoi("month", arr[0], 1, 12)
+			date[1] = t.atoi("day", arr[1], 1, 31)
 		} else if lunarDate, found := t.normalizer.holiLunar[holi]; found {
 			arr := strings.Split(lunarDate, "-")
-			date[0], _ = strconv.Atoi(arr[0])
-			date[1], _ = strconv.Atoi(arr[1])
-			lsConverter := NewLunarSolarConverter()
+			date[0] = t.atoi("month", arr[0], 1, 12)
+			date[1] = t.atoi("day", arr[1], 1, 31)
 			lunar := Lunar{
 				Year:  t.tp[0],
 				Month: date[0],
 				Day:   date[1],
 			}
-			solar := lsConverter.LunarToSolar(lunar)
+			if !lunarSolarConverter.IsSupported(lunar) {
+				t.setErr(&RangeError{Field: "lunar year", Value: strconv.Itoa(lunar.Year), Min: lunarSolarConverter.MinYear(), Max: lunarSolarConverter.MaxYear()})
+				return
+			}
+			solar := lunarSolarConverter.LunarToSolar(lunar)
 			t.tp[0] = solar.Year
 			date[0] = solar.Month
 			date[1] = solar.Day
@@ -765,10 +1517,15 @@ func (t *TimeUnit) normSetHoliday() {
 			if holi == "小寒" || holi == "大寒" {
 				t.tp[0] += 1
 			}
-			date = t.china24St(t.tp[0], holi)
+			if t.tp[0] < solarTermMinYear || t.tp[0] > solarTermMaxYear {
+				t.setErr(&RangeError{Field: "solar term year", Value: strconv.Itoa(t.tp[0]), Min: solarTermMinYear, Max: solarTermMaxYear})
+				return
+			}
+			date = china24St(t.tp[0], holi)
 		}
 		t.tp[1] = date[0]
 		t.tp[2] = date[1]
+		t.sources[1], t.sources[2] = FIELD_EXPLICIT, FIELD_EXPLICIT
 		break
 	}
 }
@@ -781,22 +1538,25 @@ func (t *TimeUnit) normSetTotal() {
 	t.calcNormSetTotalDay()
 }
 
+var (
+	totalHMSPattern = regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]", 0)
+	totalHMPattern  = regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]", 0)
+)
+
 func (t *TimeUnit) calcNormSetTotalTime() {
-	pattern := regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]:[0-5]?[0-9]", 0)
-	if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+	if match := t.find(totalHMSPattern, t.expTime); match != nil {
 		arr := strings.Split(match.String(), ":")
-		t.tp[3], _ = strconv.Atoi(arr[0])
-		t.tp[4], _ = strconv.Atoi(arr[1])
-		t.tp[5], _ = strconv.Atoi(arr[2])
+		t.tp[3] = t.atoi("hour", arr[0], 0, 24)
+		t.tp[4] = t.atoi("minute", arr[1], 0, 59)
+		t.tp[5] = t.atoi("second", arr[2], 0, 59)
 		// 处理倾向于未来时间的情况
 		t.preferFuture(3)
 		t.isAllDayTime = false
 	} else {
-		pattern := regexp2.MustCompile("(?<!(周|星期))([0-2]?[0-9]):[0-5]?[0-9]", 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+		if match := t.find(totalHMPattern, t.expTime); match != nil {
 			arr := strings.Split(match.String(), ":")
-			t.tp[3], _ = strconv.Atoi(arr[0])
-			t.tp[4], _ = strconv.Atoi(arr[1])
+			t.tp[3] = t.atoi("hour", arr[0], 0, 24)
+			t.tp[4] = t.atoi("minute", arr[1], 0, 59)
 			// 处理倾向于未来时间的情况
 			t.preferFuture(3)
 			t.isAllDayTime = false
@@ -804,31 +1564,33 @@ func (t *TimeUnit) calcNormSetTotalTime() {
 	}
 }
 
+// totalDaytimeRules 中午、下午、晚上等时段规则
+var totalDaytimeRules = []struct {
+	Pattern *regexp2.Regexp
+	Point   RangeTimeEnum
+}{
+	{Pattern: regexp2.MustCompile("(中午)|(午间)", 0), Point: NOON},
+	{Pattern: regexp2.MustCompile("(下午)|(午后)|(pm)|(PM)", 0), Point: AFTERNOON},
+	{Pattern: regexp2.MustCompile("晚", 0), Point: NIGHT},
+}
+
 // calcNormSetTotalDaytime 增加了:固定形式时间表达式的
 // 中午,午间,下午,午后,晚上,傍晚,晚间,晚,pm,PM
 // 的正确时间计算，规约同上
 func (t *TimeUnit) calcNormSetTotalDaytime() {
-	cases := []struct {
-		Reg   string
-		Point RangeTimeEnum
-	}{
-		{Reg: "(中午)|(午间)", Point: NOON},
-		{Reg: "(下午)|(午后)|(pm)|(PM)", Point: AFTERNOON},
-		{Reg: "晚", Point: NIGHT},
-	}
-	for _, c := range cases {
+	for _, c := range totalDaytimeRules {
 		endTime := 11
 		if c.Point == NOON {
 			endTime = 10
 		}
-		pattern := regexp2.MustCompile(c.Reg, 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
+		if match := t.find(c.Pattern, t.expTime); match != nil {
 			if t.tp[3] >= 0 && t.tp[3] <= endTime {
 				t.tp[3] += 12
 			} else if c.Point == NIGHT && t.tp[3] == 12 {
 				t.tp[3] = 0
 			} else if t.tp[3] == -1 {
-				t.tp[3] = int(c.Point)
+				t.tp[3] = t.normalizer.dayPeriodHour(c.Point)
+				t.period = c.Point
 			}
 			// 处理倾向于未来时间的情况
 			t.preferFuture(3)
@@ -837,38 +1599,43 @@ func (t *TimeUnit) calcNormSetTotalDaytime() {
 	}
 }
 
+// totalDayRules 固定形式的日期规则
+var totalDayRules = []struct {
+	Pattern *regexp2.Regexp
+	Spliter string
+}{
+	{Pattern: regexp2.MustCompile("[0-9]?[0-9]?[0-9]{2}-((10)|(11)|(12)|([1-9]))-((?<!\\d))([0-3][0-9]|[1-9])", 0), Spliter: "-"},
+	{Pattern: regexp2.MustCompile("((10)|(11)|(12)|([1-9]))/((?<!\\d))([0-3][0-9]|[1-9])/[0-9]?[0-9]?[0-9]{2}", 0), Spliter: "/"},
+	{Pattern: regexp2.MustCompile("[0-9]?[0-9]?[0-9]{2}\\.((10)|(11)|(12)|([1-9]))\\.((?<!\\d))([0-3][0-9]|[1-9])", 0), Spliter: "."}, // 增加了:固定形式时间表达式 年.月.日 的正确识别
+}
+
 func (t *TimeUnit) calcNormSetTotalDay() {
-	cases := [][]string{
-		{"[0-9]?[0-9]?[0-9]{2}-((10)|(11)|(12)|([1-9]))-((?<!\\d))([0-3][0-9]|[1-9])", "-"},
-		{"((10)|(11)|(12)|([1-9]))/((?<!\\d))([0-3][0-9]|[1-9])/[0-9]?[0-9]?[0-9]{2}", "/"},
-		{"[0-9]?[0-9]?[0-9]{2}\\.((10)|(11)|(12)|([1-9]))\\.((?<!\\d))([0-3][0-9]|[1-9])", "/"}, // 增加了:固定形式时间表达式 年.月.日 的正确识别
-	}
-	for _, c := range cases {
-		pattern := regexp2.MustCompile(c[0], 0)
-		if match, _ := pattern.FindStringMatch(t.expTime); match != nil {
-			arr := strings.Split(match.String(), c[1])
-			t.tp[0], _ = strconv.Atoi(arr[0])
-			t.tp[1], _ = strconv.Atoi(arr[1])
-			t.tp[2], _ = strconv.Atoi(arr[2])
+	for _, c := range totalDayRules {
+		if match := t.find(c.Pattern, t.expTime); match != nil {
+			arr := strings.Split(match.String(), c.Spliter)
+			t.tp[0] = t.atoi("year", arr[0], 0, maxYear)
+			t.tp[1] = t.atoi("month", arr[1], 1, 12)
+			t.tp[2] = t.atoi("day", arr[2], 1, 31)
 		}
 	}
 }
 
 // modifyTimeBase 该方法用于更新timeBase使之具有上下文关联性
 func (t *TimeUnit) modifyTimeBase() {
-	if !t.normalizer.isTimeSpan {
-		if t.tp[0] >= 30 && t.tp[0] < 100 {
+	if !t.isTimeSpan {
+		pivot := t.normalizer.twoDigitYearPivot
+		if t.tp[0] >= pivot && t.tp[0] < 100 {
 			t.tp[0] = 1900 + t.tp[0]
-		} else if t.tp[0] > 0 && t.tp[0] < 30 {
+		} else if t.tp[0] > 0 && t.tp[0] < pivot {
 			t.tp[0] = 2000 + t.tp[0]
 		}
-		timeGrid := NewTimePointFromTime(t.normalizer.timeBase)
+		timeGrid := NewTimePointFromTime(t.state.timeBase)
 		for idx, v := range t.tp {
 			if v != -1 {
 				timeGrid[idx] = t.tp[idx]
 			}
 		}
-		t.normalizer.timeBase = timeGrid.ToTime(t.normalizer.timeBase.Location())
+		t.state.timeBase = timeGrid.ToTime(t.state.timeBase.Location())
 	}
 }
 
@@ -882,12 +1649,19 @@ type SolarTermData struct {
 	Years [][]int
 }
 
+const (
+	// solarTermMinYear 支持计算节气的最小年份
+	solarTermMinYear = 1900
+	// solarTermMaxYear 支持计算节气的最大年份
+	solarTermMaxYear = 2099
+)
+
 // china24St 二十世纪和二十一世纪，24节气计算
 // :param year: 年份
 // :param china_st: 节气
 //
 //	:return: 节气日期（月, 日）
-func (t *TimeUnit) china24St(year int, chinaSt string) []int {
+func china24St(year int, chinaSt string) []int {
 	var stKey []float64
 	if year/100 == 19 || year == 2000 {
 		// 20世纪 key值
@@ -1042,28 +1816,30 @@ func (t *TimeUnit) china24St(year int, chinaSt string) []int {
 // 3. 晚上/傍晚/晚间/晚1-11点视为13-23点，12点视为0点
 // 4. 0-11点pm/PM视为12-23点
 func (t *TimeUnit) normCheckKeyword() {
-	cases := []struct {
-		Reg   string
-		Point RangeTimeEnum
-	}{
-		{Reg: "凌晨", Point: DAY_BREAK},
-		{Reg: `早上|早晨|早间|晨间|今早|明早|早|清晨`, Point: EARLY_MORNING},
-		{Reg: "上午", Point: MORNING},
-		{Reg: `(中午)|(午间)|白天`, Point: NOON},
-		{Reg: `(下午)|(午后)|(pm)|(PM)`, Point: AFTERNOON},
-		{Reg: `晚上|夜间|夜里|今晚|明晚|晚|夜里`, Point: LATE_NIGHT},
-	}
-	for _, c := range cases {
-		t.calcNormCheckKeyword(c.Reg, c.Point)
+	for _, c := range checkKeywordRules {
+		t.calcNormCheckKeyword(c.Word, c.Pattern, c.Point)
 	}
 }
 
-func (t *TimeUnit) calcNormCheckKeyword(reg string, timepoint RangeTimeEnum) {
+// checkKeywordRules 时段关键字规则，Pattern为空时按Word匹配
+var checkKeywordRules = []struct {
+	Word    string
+	Pattern *regexp.Regexp
+	Point   RangeTimeEnum
+}{
+	{Word: "凌晨", Point: DAY_BREAK},
+	{Pattern: regexp.MustCompile(`早上|早晨|早间|晨间|今早|明早|早|清晨`), Point: EARLY_MORNING},
+	{Word: "上午", Point: MORNING},
+	{Pattern: regexp.MustCompile(`(中午)|(午间)|白天`), Point: NOON},
+	{Pattern: regexp.MustCompile(`(下午)|(午后)|(pm)|(PM)`), Point: AFTERNOON},
+	{Pattern: regexp.MustCompile(`晚上|夜间|夜里|今晚|明晚|晚|夜里`), Point: LATE_NIGHT},
+}
+
+func (t *TimeUnit) calcNormCheckKeyword(word string, pattern *regexp.Regexp, timepoint RangeTimeEnum) {
 	var matched bool
-	if timepoint == DAY_BREAK || timepoint == MORNING {
-		matched = strings.Contains(t.expTime, reg)
+	if pattern == nil {
+		matched = strings.Contains(t.expTime, word)
 	} else {
-		pattern := regexp.MustCompile(reg)
 		matched = pattern.MatchString(t.expTime)
 	}
 	if !matched {
@@ -1073,7 +1849,8 @@ func (t *TimeUnit) calcNormCheckKeyword(reg string, timepoint RangeTimeEnum) {
 		t.isMorning = true
 		if t.tp[3] == -1 {
 			// 增加对没有明确时间点，只写了“凌晨”这种情况的处理
-			t.tp[3] = int(timepoint)
+			t.tp[3] = t.normalizer.dayPeriodHour(timepoint)
+			t.period = timepoint
 		} else if t.tp[3] > 12 && t.tp[3] <= 23 {
 			t.tp[3] -= 12
 		} else if t.tp[3] == 0 {
@@ -1085,21 +1862,18 @@ func (t *TimeUnit) calcNormCheckKeyword(reg string, timepoint RangeTimeEnum) {
 			t.tp[3] += 12
 		} else if t.tp[3] == -1 {
 			// 增加对没有明确时间点，只写了“中午/午间”这种情况的处理
-			t.tp[3] = int(NOON)
+			t.tp[3] = t.normalizer.dayPeriodHour(NOON)
+			t.period = NOON
 		}
 	} else {
-		pattern := regexp.MustCompile(reg)
-		matched = pattern.MatchString(t.expTime)
-		if !matched {
-			return
-		}
 		if t.tp[3] >= 0 && t.tp[3] <= 11 {
 			t.tp[3] += 12
 		} else if timepoint == LATE_NIGHT && t.tp[3] == 12 {
 			t.tp[3] = 0
 		} else if t.tp[3] == -1 {
 			// 增加对没有明确时间点，只写了“中午/午间”这种情况的处理
-			t.tp[3] = int(timepoint)
+			t.tp[3] = t.normalizer.dayPeriodHour(timepoint)
+			t.period = timepoint
 		}
 	}
 	t.preferFuture(3)
@@ -1117,7 +1891,7 @@ func (t *TimeUnit) preferFutureWeek(week int, cur time.Time) time.Time {
 		return cur
 	}
 	// 获取当前是在周几，如果识别到的时间小于当前时间，则识别时间为下一周
-	if int(time.Now().Weekday()) > week {
+	if t.normalizer.weekOffset(int(t.state.ref.Weekday())) > t.normalizer.weekOffset(week) {
 		cur = cur.AddDate(0, 0, 7)
 	}
 	return cur
@@ -1157,18 +1931,19 @@ func (t *TimeUnit) preferFuture(checkTimeIndex int) {
 		return
 	}
 	// 5. 获取当前时间，如果识别到的时间小于当前时间，则将其上的所有级别时间设置为当前时间，并且其上一级的时间步长+1
-	basePoint := NewTimePointFromTime(t.normalizer.timeBase)
+	basePoint := NewTimePointFromTime(t.state.timeBase)
 	t.noYear = t.tp[0] == -1
 	if basePoint[checkTimeIndex] < t.tp[checkTimeIndex] {
 		return
 	}
 	// 准备增加的时间单位是被检查的时间的上一级，将上一级时间+1
 	{
-		curr := t.addTime(t.normalizer.timeBase, checkTimeIndex-1)
+		curr := t.addTime(t.state.timeBase, checkTimeIndex-1)
 		currPoint := NewTimePointFromTime(curr)
 		idx := 0
 		for idx < checkTimeIndex {
 			t.tp[idx] = currPoint[idx]
+			t.sources[idx] = FIELD_BASE
 			idx += 1
 		}
 	}
@@ -1180,7 +1955,7 @@ func (t *TimeUnit) checkTime(timePoint TimePoint) {
 		return
 	}
 	// check the month
-	basePoint := NewTimePointFromTime(t.normalizer.timeBase)
+	basePoint := NewTimePointFromTime(t.state.timeBase)
 	if timePoint[1] == basePoint[1] && timePoint[2] > basePoint[2] {
 		timePoint[0] -= 1
 	}
@@ -1196,6 +1971,7 @@ func (t *TimeUnit) checkContextTime(checkTimeIndex int) {
 	for idx < checkTimeIndex {
 		if t.tp[idx] == -1 && t.tpOrigin[idx] != -1 {
 			t.tp[idx] = t.tpOrigin[idx]
+			t.sources[idx] = FIELD_CONTEXT
 		}
 		idx += 1
 	}
@@ -0,0 +1,98 @@
+package timenlp
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ISOWeekLabel 时间所在的ISO 8601周，格式为“2025-W35”，跨年的周按周四所在的年份计算，如2024年12月30日为“2025-W01”
+func ISOWeekLabel(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// isoWeekStart ISO 8601年份year第week周的周一
+func isoWeekStart(year int, week int, loc *time.Location) time.Time {
+	// 1月4日总是在第1周
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, 7*(week-1))
+}
+
+// isoWeeksInYear ISO 8601年份year包含的周数，为52或53
+func isoWeeksInYear(year int) int {
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// weekOfMonth 按月内第几周的计算规则求year年month月第week周的第一天和包含的天数，max为该月的周数
+func (n *TimeNormalizer) weekOfMonth(year int, month time.Month, week int, loc *time.Location) (start time.Time, days int, max int) {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	next := first.AddDate(0, 1, 0)
+	monthDays := daysIn(year, month)
+	switch n.weekOfMonthConvention {
+	case WeekOfMonthCalendar:
+		offset := n.weekOffset(int(first.Weekday()))
+		max = (offset + monthDays + 6) / 7
+		start = first.AddDate(0, 0, 7*(week-1)-offset)
+		end := start.AddDate(0, 0, 7)
+		if start.Before(first) {
+			start = first
+		}
+		if end.After(next) {
+			end = next
+		}
+		days = int(end.Sub(start).Hours()+12) / 24
+	case WeekOfMonthISO:
+		// 第一个周四所在的周为第1周
+		thursday := 1 + (int(time.Thursday)-int(first.Weekday())+7)%7
+		max = (monthDays-thursday)/7 + 1
+		start = first.AddDate(0, 0, thursday-4+7*(week-1))
+		days = 7
+	default:
+		max = (monthDays + 6) / 7
+		start = first.AddDate(0, 0, 7*(week-1))
+		days = 7
+		if rest := monthDays - 7*(week-1); rest < days {
+			days = rest
+		}
+	}
+	return start, days, max
+}
+
+// nthWeekday [start, end)内第nth个星期几的日期，weekday为“1”-“7”，为空时表示工作日（不包括周末和节假日），
+// nth为负数时表示倒数第几个，不存在时day为零值，count为区间内符合条件的天数
+func (n *TimeNormalizer) nthWeekday(start time.Time, end time.Time, weekday string, nth int) (day time.Time, count int) {
+	var days []time.Time
+	for date := start; date.Before(end); date = date.AddDate(0, 0, 1) {
+		if weekday == "" {
+			if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday || (n.holidays != nil && n.holidays.IsHoliday(date)) {
+				continue
+			}
+		} else if wd, _ := strconv.Atoi(weekday); date.Weekday() != time.Weekday(wd%7) {
+			continue
+		}
+		days = append(days, date)
+	}
+	count = len(days)
+	switch {
+	case nth > 0 && nth <= count:
+		day = days[nth-1]
+	case nth < 0 && -nth <= count:
+		day = days[count+nth]
+	}
+	return day, count
+}
+
+// nthDay [start, end)内第nth天的日期，nth为负数时表示倒数第几天，不存在时day为零值，count为区间的天数
+func nthDay(start time.Time, end time.Time, nth int) (day time.Time, count int) {
+	count = int(end.Sub(start).Hours()+12) / 24
+	switch {
+	case nth > 0 && nth <= count:
+		day = start.AddDate(0, 0, nth-1)
+	case nth < 0 && -nth <= count:
+		day = end.AddDate(0, 0, nth)
+	}
+	return day, count
+}